@@ -0,0 +1,294 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+func (app *application) createAccreditationHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		ProgramID int64     `json:"program_id"`
+		Kind      string    `json:"kind"`
+		Body      string    `json:"body"`
+		Level     int32     `json:"level"`
+		Scope     string    `json:"scope"`
+		ValidFrom data.Day  `json:"valid_from"`
+		ValidTo   *data.Day `json:"valid_to"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	university, err := app.models.Universities.Get(universityID, "name")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	accreditation := &data.Accreditation{
+		UniversityID:   university.ID,
+		UniversityName: university.Name,
+		ProgramID:      input.ProgramID,
+		Kind:           input.Kind,
+		Body:           input.Body,
+		Level:          input.Level,
+		Scope:          input.Scope,
+		ValidFrom:      input.ValidFrom,
+		ValidTo:        input.ValidTo,
+	}
+
+	// only CHED makes COE and COD designations
+	if accreditation.Kind != data.AccreditationKindAccreditation && accreditation.Body == "" {
+		accreditation.Body = "CHED"
+	}
+
+	v := validator.New()
+
+	data.ValidateAccreditation(v, accreditation)
+
+	if !app.validateUniversityProgram(w, r, v, accreditation.UniversityID, accreditation.ProgramID) {
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Accreditations.Insert(accreditation)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v0/accreditations/%d", accreditation.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"accreditation": accreditation}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showAccreditationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	accreditation, err := app.models.Accreditations.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"accreditation": accreditation}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateAccreditationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	accreditation, err := app.models.Accreditations.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// to handle partial updates, we use pointers
+	// to distinguish between a field that was not provided
+	var input struct {
+		ProgramID *int64    `json:"program_id"`
+		Kind      *string   `json:"kind"`
+		Body      *string   `json:"body"`
+		Level     *int32    `json:"level"`
+		Scope     *string   `json:"scope"`
+		ValidFrom *data.Day `json:"valid_from"`
+		ValidTo   *data.Day `json:"valid_to"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.ProgramID != nil {
+		accreditation.ProgramID = *input.ProgramID
+	}
+	if input.Kind != nil {
+		accreditation.Kind = *input.Kind
+	}
+	if input.Body != nil {
+		accreditation.Body = *input.Body
+	}
+	if input.Level != nil {
+		accreditation.Level = *input.Level
+	}
+	if input.Scope != nil {
+		accreditation.Scope = *input.Scope
+	}
+	if input.ValidFrom != nil {
+		accreditation.ValidFrom = *input.ValidFrom
+	}
+	if input.ValidTo != nil {
+		accreditation.ValidTo = input.ValidTo
+	}
+
+	v := validator.New()
+
+	data.ValidateAccreditation(v, accreditation)
+
+	if !app.validateUniversityProgram(w, r, v, accreditation.UniversityID, accreditation.ProgramID) {
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Accreditations.Update(accreditation)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"accreditation": accreditation}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteAccreditationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Accreditations.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "accreditation successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listUniversityAccreditationsHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Universities.Get(universityID, "id")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.listAccreditations(w, r, universityID)
+}
+
+func (app *application) listAccreditationsHandler(w http.ResponseWriter, r *http.Request) {
+	app.listAccreditations(w, r, 0)
+}
+
+// listAccreditations writes the accreditations matching the query string,
+// limited to a single university unless universityID is zero
+func (app *application) listAccreditations(w http.ResponseWriter, r *http.Request, universityID int64) {
+	var input struct {
+		data.AccreditationQuery
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.UniversityID = universityID
+	input.Kind = app.readString(qs, "kind", "")
+	input.Body = app.readString(qs, "body", "")
+	input.Level = app.readInt(qs, "level", 0, v)
+	input.Scope = app.readString(qs, "scope", "")
+	input.Status = app.readString(qs, "status", "")
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "id")
+	input.Filters.SortSafelist = []string{"id", "body", "level", "scope", "valid_from", "-id", "-body", "-level", "-scope", "-valid_from"}
+	input.Filters.Cursor = app.readString(qs, "cursor", "")
+	input.Filters.SortTypes = map[string]string{"level": "integer", "valid_from": "date"}
+
+	data.ValidateAccreditationQuery(v, input.AccreditationQuery)
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	accreditations, metadata, err := app.models.Accreditations.GetAll(input.AccreditationQuery, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"accreditations": accreditations, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
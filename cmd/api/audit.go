@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+func (app *application) listUniversityAuditHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	app.listAudit(w, r, id)
+}
+
+func (app *application) listAuditHandler(w http.ResponseWriter, r *http.Request) {
+	app.listAudit(w, r, 0)
+}
+
+// listAudit writes the audit events for a single university, or for all
+// universities when universityID is zero
+func (app *application) listAudit(w http.ResponseWriter, r *http.Request, universityID int64) {
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-created_at")
+	input.Filters.SortSafelist = []string{"id", "created_at", "-id", "-created_at"}
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	events, metadata, err := app.models.Audit.GetAll(universityID, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"audit_events": events, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
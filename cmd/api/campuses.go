@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+func (app *application) createCampusHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Name      string   `json:"name"`
+		Address   string   `json:"address"`
+		City      string   `json:"city"`
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+		Founded   int32    `json:"founded"`
+		Website   string   `json:"website"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	_, err = app.models.Universities.Get(universityID, "id")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	campus := &data.Campus{
+		UniversityID: universityID,
+		Name:         input.Name,
+		Address:      input.Address,
+		City:         input.City,
+		Latitude:     input.Latitude,
+		Longitude:    input.Longitude,
+		Founded:      input.Founded,
+		Website:      input.Website,
+	}
+
+	v := validator.New()
+
+	if data.ValidateCampus(v, campus); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Campuses.Insert(campus, app.auditInfo(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateCampus):
+			v.AddError("name", "the university already has a campus with this name")
+			app.failedValidationResponse(w, r, v.Errors)
+		// the university was deleted since it was fetched
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v0/universities/%d/campuses/%d", universityID, campus.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"campus": campus}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showCampusHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readCampusIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	campus, err := app.models.Campuses.Get(universityID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"campus": campus}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateCampusHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readCampusIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	campus, err := app.models.Campuses.Get(universityID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// to handle partial updates, we use pointers
+	// to distinguish between a field that was not provided
+	var input struct {
+		Name      *string  `json:"name"`
+		Address   *string  `json:"address"`
+		City      *string  `json:"city"`
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+		Founded   *int32   `json:"founded"`
+		Website   *string  `json:"website"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Name != nil {
+		campus.Name = *input.Name
+	}
+	if input.Address != nil {
+		campus.Address = *input.Address
+	}
+	if input.City != nil {
+		campus.City = *input.City
+	}
+	if input.Latitude != nil {
+		campus.Latitude = input.Latitude
+	}
+	if input.Longitude != nil {
+		campus.Longitude = input.Longitude
+	}
+	if input.Founded != nil {
+		campus.Founded = *input.Founded
+	}
+	if input.Website != nil {
+		campus.Website = *input.Website
+	}
+
+	v := validator.New()
+
+	if data.ValidateCampus(v, campus); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Campuses.Update(campus, app.auditInfo(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateCampus):
+			v.AddError("name", "the university already has a campus with this name")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"campus": campus}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteCampusHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readCampusIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Campuses.Delete(universityID, id, app.auditInfo(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "campus successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listUniversityCampusesHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Universities.Get(universityID, "id")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	campuses, err := app.models.Campuses.GetAllForUniversity(universityID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"campuses": campuses}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+)
+
+type contextKey string
+
+const (
+	apiKeyContextKey    = contextKey("apiKey")
+	requestIDContextKey = contextKey("requestID")
+	userContextKey      = contextKey("user")
+)
+
+// contextSetAPIKey returns a copy of the request with the API key added to its context
+func (app *application) contextSetAPIKey(r *http.Request, key *data.APIKey) *http.Request {
+	ctx := context.WithValue(r.Context(), apiKeyContextKey, key)
+	return r.WithContext(ctx)
+}
+
+// contextGetAPIKey retrieves the API key from the request context. It is only
+// called after the authenticate middleware has run, so a missing value is unexpected
+func (app *application) contextGetAPIKey(r *http.Request) *data.APIKey {
+	key, ok := r.Context().Value(apiKeyContextKey).(*data.APIKey)
+	if !ok {
+		panic("missing api key value in request context")
+	}
+
+	return key
+}
+
+// contextSetUser returns a copy of the request with the user added to its context
+func (app *application) contextSetUser(r *http.Request, user *data.User) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	return r.WithContext(ctx)
+}
+
+// contextGetUser retrieves the user from the request context. It is only
+// called after the authenticate middleware has run, so a missing value is unexpected
+func (app *application) contextGetUser(r *http.Request) *data.User {
+	user, ok := r.Context().Value(userContextKey).(*data.User)
+	if !ok {
+		panic("missing user value in request context")
+	}
+
+	return user
+}
+
+// contextSetRequestID returns a copy of the request with the request ID added to its context
+func (app *application) contextSetRequestID(r *http.Request, requestID string) *http.Request {
+	ctx := context.WithValue(r.Context(), requestIDContextKey, requestID)
+	return r.WithContext(ctx)
+}
+
+// contextGetRequestID retrieves the request ID from the request context. Unlike
+// the other getters it doesn't panic, because errors can be logged before the
+// requestID middleware has run
+func (app *application) contextGetRequestID(r *http.Request) string {
+	requestID, _ := r.Context().Value(requestIDContextKey).(string)
+	return requestID
+}
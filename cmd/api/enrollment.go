@@ -0,0 +1,349 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+func (app *application) createEnrollmentStatHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		AcademicYear data.AcademicYear `json:"academic_year"`
+		Level        string            `json:"level"`
+		Sex          string            `json:"sex"`
+		Enrolled     *int32            `json:"enrolled"`
+		Graduates    *int32            `json:"graduates"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	university, err := app.models.Universities.Get(universityID, "name")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	stat := &data.EnrollmentStat{
+		UniversityID:   university.ID,
+		UniversityName: university.Name,
+		AcademicYear:   input.AcademicYear,
+		Level:          input.Level,
+		Sex:            input.Sex,
+	}
+
+	v := validator.New()
+
+	// counts of zero are valid, so missing ones are told apart with pointers
+	v.Check(input.Enrolled != nil, "enrolled", "must be provided")
+	if input.Enrolled != nil {
+		stat.Enrolled = *input.Enrolled
+	}
+
+	v.Check(input.Graduates != nil, "graduates", "must be provided")
+	if input.Graduates != nil {
+		stat.Graduates = *input.Graduates
+	}
+
+	if data.ValidateEnrollmentStat(v, stat); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Enrollment.Insert(stat)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateEnrollmentStat):
+			v.AddError("academic_year", "enrollment for this academic year, level and sex has already been recorded")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v0/universities/%d/enrollment/%d", universityID, stat.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"enrollment_stat": stat}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showEnrollmentStatHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readStatIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	stat, err := app.models.Enrollment.Get(universityID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"enrollment_stat": stat}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateEnrollmentStatHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readStatIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	stat, err := app.models.Enrollment.Get(universityID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// to handle partial updates, we use pointers
+	// to distinguish between a field that was not provided
+	var input struct {
+		AcademicYear *data.AcademicYear `json:"academic_year"`
+		Level        *string            `json:"level"`
+		Sex          *string            `json:"sex"`
+		Enrolled     *int32             `json:"enrolled"`
+		Graduates    *int32             `json:"graduates"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.AcademicYear != nil {
+		stat.AcademicYear = *input.AcademicYear
+	}
+	if input.Level != nil {
+		stat.Level = *input.Level
+	}
+	if input.Sex != nil {
+		stat.Sex = *input.Sex
+	}
+	if input.Enrolled != nil {
+		stat.Enrolled = *input.Enrolled
+	}
+	if input.Graduates != nil {
+		stat.Graduates = *input.Graduates
+	}
+
+	v := validator.New()
+
+	if data.ValidateEnrollmentStat(v, stat); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Enrollment.Update(stat)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateEnrollmentStat):
+			v.AddError("academic_year", "enrollment for this academic year, level and sex has already been recorded")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"enrollment_stat": stat}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteEnrollmentStatHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readStatIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Enrollment.Delete(universityID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "enrollment stat successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listUniversityEnrollmentHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Universities.Get(universityID, "id")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.listEnrollment(w, r, universityID)
+}
+
+func (app *application) listEnrollmentHandler(w http.ResponseWriter, r *http.Request) {
+	app.listEnrollment(w, r, 0)
+}
+
+// readEnrollmentQuery reads the filters shared by the enrollment lists and
+// aggregates from the query string
+func (app *application) readEnrollmentQuery(qs url.Values, v *validator.Validator) data.EnrollmentQuery {
+	var q data.EnrollmentQuery
+
+	q.AcademicYear = app.readAcademicYear(qs, "academic_year", v)
+	q.Level = app.readString(qs, "level", "")
+	q.Sex = app.readString(qs, "sex", "")
+	q.RegionCode = app.readString(qs, "region", "")
+	q.InstitutionType = app.readString(qs, "institution_type", "")
+
+	return q
+}
+
+// listEnrollment writes the stats matching the query string, limited to a
+// single university unless universityID is zero
+func (app *application) listEnrollment(w http.ResponseWriter, r *http.Request, universityID int64) {
+	var input struct {
+		data.EnrollmentQuery
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.EnrollmentQuery = app.readEnrollmentQuery(qs, v)
+	input.UniversityID = universityID
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-academic_year")
+	input.Filters.SortSafelist = []string{"id", "academic_year", "enrolled", "graduates", "-id", "-academic_year", "-enrolled", "-graduates"}
+	input.Filters.Cursor = app.readString(qs, "cursor", "")
+	input.Filters.SortTypes = map[string]string{"academic_year": "integer", "enrolled": "integer", "graduates": "integer"}
+
+	data.ValidateEnrollmentQuery(v, input.EnrollmentQuery)
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	stats, metadata, err := app.models.Enrollment.GetAll(input.EnrollmentQuery, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"enrollment_stats": stats, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// aggregateEnrollmentByRegionHandler writes the yearly enrollment totals of
+// each region
+func (app *application) aggregateEnrollmentByRegionHandler(w http.ResponseWriter, r *http.Request) {
+	app.aggregateEnrollment(w, r, "region")
+}
+
+// aggregateEnrollmentByInstitutionTypeHandler writes the yearly enrollment
+// totals of each institution type
+func (app *application) aggregateEnrollmentByInstitutionTypeHandler(w http.ResponseWriter, r *http.Request) {
+	app.aggregateEnrollment(w, r, "institution_type")
+}
+
+func (app *application) aggregateEnrollment(w http.ResponseWriter, r *http.Request, groupBy string) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input := app.readEnrollmentQuery(qs, v)
+	input.UniversityID = int64(app.readInt(qs, "university_id", 0, v))
+
+	if data.ValidateEnrollmentQuery(v, input); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	aggregates, err := app.models.Enrollment.Aggregate(groupBy, input)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"aggregates": aggregates}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
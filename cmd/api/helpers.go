@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/liamgluna/kolehiyo/internal/data"
 	"github.com/liamgluna/kolehiyo/internal/validator"
 )
 
@@ -27,6 +28,62 @@ func (app *application) readIDParam(r *http.Request) (int64, error) {
 	return id, nil
 }
 
+func (app *application) readVersionParam(r *http.Request) (int32, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	version, err := strconv.ParseInt(params.ByName("version"), 10, 32)
+	if err != nil || version < 1 {
+		return 0, errors.New("invalid version parameter")
+	}
+
+	return int32(version), nil
+}
+
+func (app *application) readCampusIDParam(r *http.Request) (int64, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.ParseInt(params.ByName("campus_id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid campus_id parameter")
+	}
+
+	return id, nil
+}
+
+func (app *application) readNameIDParam(r *http.Request) (int64, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.ParseInt(params.ByName("name_id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid name_id parameter")
+	}
+
+	return id, nil
+}
+
+func (app *application) readStatIDParam(r *http.Request) (int64, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.ParseInt(params.ByName("stat_id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid stat_id parameter")
+	}
+
+	return id, nil
+}
+
+// readCodeParam returns the PSGC code in the URL
+func (app *application) readCodeParam(r *http.Request) (string, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	code := params.ByName("code")
+	if !validator.Matches(code, data.PSGCRX) {
+		return "", errors.New("invalid code parameter")
+	}
+
+	return code, nil
+}
+
 func (app *application) writeJSON(w http.ResponseWriter, status int, data envelope, headers http.Header) error {
 	js, err := json.MarshalIndent(data, "", "\t")
 	if err != nil {
@@ -118,6 +175,18 @@ func (app *application) readString(qs url.Values, key string, defaultValue strin
 	return s
 }
 
+// readCSV splits the comma separated value of the specified key from the
+// query string into a slice. If no key exists, it returns the defaultValue
+func (app *application) readCSV(qs url.Values, key string, defaultValue []string) []string {
+	csv := qs.Get(key)
+
+	if csv == "" {
+		return defaultValue
+	}
+
+	return strings.Split(csv, ",")
+}
+
 // readInt returns the value of the specified key from the query string.
 // If no key exists, it returns the defaultValue
 func (app *application) readInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
@@ -135,3 +204,141 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 
 	return i
 }
+
+// readFloat returns the value of the specified key from the query string.
+// If no key exists, it returns the defaultValue
+func (app *application) readFloat(qs url.Values, key string, defaultValue float64, v *validator.Validator) float64 {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		v.AddError(key, "must be a number")
+		return defaultValue
+	}
+
+	return f
+}
+
+// readBool returns the value of the specified key from the query string.
+// If no key exists, it returns the defaultValue
+func (app *application) readBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+
+	return b
+}
+
+// readDate returns the "2006", "2006-01" or "2006-01-02" value of the
+// specified key from the query string. If no key exists, it returns the zero
+// Date
+func (app *application) readDate(qs url.Values, key string, v *validator.Validator) data.Date {
+	s := qs.Get(key)
+
+	if s == "" {
+		return data.Date{}
+	}
+
+	d, err := data.ParseDate(s)
+	if err != nil {
+		v.AddError(key, "must be a year, a year and month, or a full date")
+		return data.Date{}
+	}
+
+	return d
+}
+
+// readCoordinates returns the "lat,lng" value of the specified key from the
+// query string. If no key exists, it returns nil
+func (app *application) readCoordinates(qs url.Values, key string, v *validator.Validator) *data.Coordinates {
+	s := qs.Get(key)
+
+	if s == "" {
+		return nil
+	}
+
+	latitude, longitude, found := strings.Cut(s, ",")
+	if !found {
+		v.AddError(key, "must be a latitude and longitude separated by a comma")
+		return nil
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latitude), 64)
+	if err != nil {
+		v.AddError(key, "must be a latitude and longitude separated by a comma")
+		return nil
+	}
+
+	lng, err := strconv.ParseFloat(strings.TrimSpace(longitude), 64)
+	if err != nil {
+		v.AddError(key, "must be a latitude and longitude separated by a comma")
+		return nil
+	}
+
+	return &data.Coordinates{Latitude: lat, Longitude: lng}
+}
+
+// readAcademicYear returns the "2024-2025" value of the specified key from
+// the query string. If no key exists, it returns zero
+func (app *application) readAcademicYear(qs url.Values, key string, v *validator.Validator) data.AcademicYear {
+	s := qs.Get(key)
+
+	if s == "" {
+		return 0
+	}
+
+	year, err := data.ParseAcademicYear(s)
+	if err != nil {
+		v.AddError(key, "must be two consecutive years separated by a hyphen")
+		return 0
+	}
+
+	return year
+}
+
+// background runs fn in a new goroutine, recovering from any panic and
+// tracking it so that the server waits for it to finish before shutting down
+func (app *application) background(fn func()) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Error(fmt.Sprintf("%v", err))
+			}
+		}()
+
+		fn()
+	}()
+}
+
+// auditInfo describes the principal making the request, for recording
+// alongside any changes made to the universities
+func (app *application) auditInfo(r *http.Request) data.AuditInfo {
+	actor := "anonymous"
+
+	if user := app.contextGetUser(r); !user.IsAnonymous() {
+		actor = fmt.Sprintf("user:%d", user.ID)
+	} else if key := app.contextGetAPIKey(r); !key.IsAnonymous() {
+		actor = fmt.Sprintf("api_key:%d", key.ID)
+	}
+
+	return data.AuditInfo{
+		Actor:     actor,
+		RequestID: app.contextGetRequestID(r),
+	}
+}
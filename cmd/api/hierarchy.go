@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+)
+
+// listUniversityChildrenHandler writes the constituent units directly under
+// a university
+func (app *application) listUniversityChildrenHandler(w http.ResponseWriter, r *http.Request) {
+	app.listUniversityHierarchy(w, r, app.models.Universities.GetChildren)
+}
+
+// listUniversityAncestorsHandler writes the chain of universities above a
+// university, starting with its parent
+func (app *application) listUniversityAncestorsHandler(w http.ResponseWriter, r *http.Request) {
+	app.listUniversityHierarchy(w, r, app.models.Universities.GetAncestors)
+}
+
+func (app *application) listUniversityHierarchy(w http.ResponseWriter, r *http.Request, get func(id int64) ([]*data.University, error)) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Universities.Get(id, "id")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	universities, err := get(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"universities": universities}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
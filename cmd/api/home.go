@@ -9,8 +9,13 @@ func (app *application) homeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := envelope{
-		"message":      "Welcome to Kolehiyo, a RESTful API for universities in the Philippines.",
-		"universities": "https://api.kolehiyo.live/v0/universities",
+		"message":        "Welcome to Kolehiyo, a RESTful API for universities in the Philippines.",
+		"universities":   "https://api.kolehiyo.live/v0/universities",
+		"programs":       "https://api.kolehiyo.live/v0/programs",
+		"accreditations": "https://api.kolehiyo.live/v0/accreditations",
+		"tuition":        "https://api.kolehiyo.live/v0/tuition",
+		"enrollment":     "https://api.kolehiyo.live/v0/enrollment",
+		"regions":        "https://api.kolehiyo.live/v0/regions",
 	}
 
 	err := app.writeJSON(w, http.StatusOK, data, nil)
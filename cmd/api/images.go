@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/blob"
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// maxImagePixels caps the dimensions of an uploaded image, since a small
+// file can decode into an enormous image
+const maxImagePixels = 50_000_000
+
+// uploadUniversityImageHandler stores an image sent as the "image" field of a
+// multipart form along with its thumbnails, and points the img_url of the
+// university at it. The attribution of the new image is read from the
+// "author", "source_url", "license" and "retrieved" fields, with retrieved
+// defaulting to the day of the upload. Earlier images are kept so that
+// reverting to an older revision still finds them.
+func (app *application) uploadUniversityImageHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	university, err := app.models.Universities.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	file, err := app.readImageFile(w, r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	img := &data.Image{
+		UniversityID: university.ID,
+		ContentType:  http.DetectContentType(file),
+		Size:         int64(len(file)),
+	}
+
+	v.Check(img.Size <= app.config.images.maxSize, "image", fmt.Sprintf("must not be larger than %d bytes", app.config.images.maxSize))
+	v.Check(validator.PermittedValue(img.ContentType, data.ImageContentTypes...), "image", "must be a JPEG, PNG or WebP image")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// the dimensions are checked before the image is decoded in full
+	config, _, err := image.DecodeConfig(bytes.NewReader(file))
+	if err != nil {
+		v.AddError("image", "must be a valid image")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if config.Width*config.Height > maxImagePixels {
+		v.AddError("image", fmt.Sprintf("must not be more than %d pixels", maxImagePixels))
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(file))
+	if err != nil {
+		v.AddError("image", "must be a valid image")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	img.Width, img.Height = src.Bounds().Dx(), src.Bounds().Dy()
+
+	university.ImgAttribution = app.readImageAttribution(r, v)
+
+	if data.ValidateImageAttribution(v, university.ImgAttribution); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Images.Insert(img)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.storeImage(img, file, src)
+	if err != nil {
+		app.discardImage(img)
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	previousImgURL := university.ImgURL
+	university.ImgURL = fmt.Sprintf("%s/%d", app.config.images.baseURL, img.ID)
+
+	// the campuses are left as they are
+	university.Campuses = nil
+
+	if data.ValidateUniversity(v, university, previousImgURL); !v.Valid() {
+		app.discardImage(img)
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Universities.Update(university, app.auditInfo(r))
+	if err != nil {
+		app.discardImage(img)
+
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v0/images/%d", img.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"image": img, "university": university}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showImageHandler writes an uploaded image, or one of its thumbnails when
+// the size query parameter is given
+func (app *application) showImageHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	size := app.readString(r.URL.Query(), "size", data.ImageSizeOriginal)
+
+	if v.Check(validator.PermittedValue(size, data.ImageSizes...), "size", "must be one of "+strings.Join(data.ImageSizes, ", ")); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	img, err := app.models.Images.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rc, err := app.blobs.Get(img.Key(size))
+	if err != nil {
+		switch {
+		case errors.Is(err, blob.ErrNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	defer rc.Close()
+
+	// an image never changes once uploaded, since a new upload gets a new id
+	w.Header().Set("Content-Type", img.SizeContentType(size))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	// the status has already been written, so a failure can only be logged
+	_, err = io.Copy(w, rc)
+	if err != nil {
+		app.logError(r, err)
+	}
+}
+
+// readImageFile returns the contents of the "image" field of a multipart
+// form. The body is limited to the maximum image size plus some room for the
+// rest of the form, so a file a little over the limit is still read and
+// rejected by validation instead.
+func (app *application) readImageFile(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, app.config.images.maxSize+1_048_576)
+
+	err := r.ParseMultipartForm(app.config.images.maxSize)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &maxBytesError):
+			return nil, fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		case errors.Is(err, http.ErrNotMultipart):
+			return nil, errors.New("body must be a multipart form")
+		default:
+			return nil, err
+		}
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		switch {
+		case errors.Is(err, http.ErrMissingFile):
+			return nil, errors.New("body must contain a file in the image field")
+		default:
+			return nil, err
+		}
+	}
+	defer file.Close()
+
+	return io.ReadAll(io.LimitReader(file, app.config.images.maxSize+1))
+}
+
+// readImageAttribution reads the attribution of an uploaded image from the
+// fields of the multipart form
+func (app *application) readImageAttribution(r *http.Request, v *validator.Validator) *data.ImageAttribution {
+	form := url.Values(r.MultipartForm.Value)
+
+	attribution := &data.ImageAttribution{
+		Author:    app.readString(form, "author", ""),
+		SourceURL: app.readString(form, "source_url", ""),
+		License:   app.readString(form, "license", ""),
+		Retrieved: data.Day(time.Now().UTC().Truncate(24 * time.Hour)),
+	}
+
+	if retrieved := form.Get("retrieved"); retrieved != "" {
+		t, err := time.Parse(time.DateOnly, retrieved)
+		if err != nil {
+			v.AddError("img_attribution.retrieved", "must be a date such as 2006-01-02")
+		}
+		attribution.Retrieved = data.Day(t)
+	}
+
+	return attribution
+}
+
+// storeImage puts the original image and each of its thumbnails in the blob
+// store
+func (app *application) storeImage(img *data.Image, file []byte, src image.Image) error {
+	err := app.blobs.Put(img.Key(data.ImageSizeOriginal), bytes.NewReader(file))
+	if err != nil {
+		return err
+	}
+
+	for size, width := range data.ImageThumbnailWidths {
+		buf := new(bytes.Buffer)
+
+		err := encodeImage(buf, thumbnail(src, width), img.SizeContentType(size))
+		if err != nil {
+			return err
+		}
+
+		err = app.blobs.Put(img.Key(size), buf)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// discardImage removes an image whose upload couldn't be completed. Failures
+// are only logged, since the upload has already failed.
+func (app *application) discardImage(img *data.Image) {
+	for _, size := range data.ImageSizes {
+		err := app.blobs.Delete(img.Key(size))
+		if err != nil {
+			app.logger.Error(err.Error(), "image_id", img.ID)
+		}
+	}
+
+	err := app.models.Images.Delete(img.ID)
+	if err != nil {
+		app.logger.Error(err.Error(), "image_id", img.ID)
+	}
+}
+
+// thumbnail scales an image down to the given width, keeping its aspect
+// ratio. Images that are already narrower keep their size.
+func thumbnail(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+
+	width = min(width, bounds.Dx())
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	return dst
+}
+
+// encodeImage writes an image in the given format. JPEG has no transparency,
+// so transparent areas are laid over white rather than turning black.
+func encodeImage(w io.Writer, img image.Image, contentType string) error {
+	if contentType == "image/png" {
+		return png.Encode(w, img)
+	}
+
+	opaque := image.NewRGBA(img.Bounds())
+	draw.Draw(opaque, opaque.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(opaque, opaque.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	return jpeg.Encode(w, opaque, &jpeg.Options{Quality: 85})
+}
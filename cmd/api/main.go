@@ -8,9 +8,12 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/liamgluna/kolehiyo/internal/blob"
 	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/mailer"
 	"github.com/liamgluna/kolehiyo/internal/vcs"
 	_ "github.com/lib/pq"
 )
@@ -33,15 +36,30 @@ type config struct {
 		burst   int
 		enabled bool
 	}
+	smtp struct {
+		host     string
+		port     int
+		username string
+		password string
+		sender   string
+	}
 	cors struct {
 		trustedOrigins []string
 	}
+	images struct {
+		dir     string
+		baseURL string
+		maxSize int64
+	}
 }
 
 type application struct {
 	config config
 	logger *slog.Logger
 	models data.Models
+	mailer mailer.Mailer
+	blobs  blob.Store
+	wg     sync.WaitGroup
 }
 
 func main() {
@@ -60,11 +78,22 @@ func main() {
 	flag.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum burst")
 	flag.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable rate limiter")
 
+	// the defaults point at a local SMTP sink such as Mailpit
+	flag.StringVar(&cfg.smtp.host, "smtp-host", "localhost", "SMTP host")
+	flag.IntVar(&cfg.smtp.port, "smtp-port", 1025, "SMTP port")
+	flag.StringVar(&cfg.smtp.username, "smtp-username", "", "SMTP username")
+	flag.StringVar(&cfg.smtp.password, "smtp-password", "", "SMTP password")
+	flag.StringVar(&cfg.smtp.sender, "smtp-sender", "Kolehiyo <no-reply@kolehiyo.live>", "SMTP sender")
+
 	flag.Func("cors-trusted-origins", "Trusted CORS origins (space separated)", func(val string) error {
 		cfg.cors.trustedOrigins = strings.Fields(val)
 		return nil
 	})
 
+	flag.StringVar(&cfg.images.dir, "images-dir", "./uploads", "Directory uploaded images are stored in")
+	flag.StringVar(&cfg.images.baseURL, "images-base-url", "https://api.kolehiyo.live/v0/images", "Base URL uploaded images are served from")
+	flag.Int64Var(&cfg.images.maxSize, "images-max-size", 5<<20, "Maximum size of an uploaded image in bytes")
+
 	displayVersion := flag.Bool("version", false, "Display version and exit")
 
 	flag.Parse()
@@ -86,10 +115,24 @@ func main() {
 
 	defer db.Close()
 
+	blobs, err := blob.NewLocal(cfg.images.dir)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	smtpMailer, err := mailer.NewSMTP(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
 	app := &application{
 		config: cfg,
 		logger: logger,
 		models: data.NewModels(db),
+		mailer: smtpMailer,
+		blobs:  blobs,
 	}
 
 	err = app.serve()
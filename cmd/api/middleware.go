@@ -1,11 +1,17 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
 	"github.com/tomasen/realip"
 	"golang.org/x/time/rate"
 )
@@ -31,6 +37,31 @@ func (app *application) recoverPanic(next http.Handler) http.Handler {
 	})
 }
 
+func (app *application) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// reuse the ID set by a proxy in front of the API, if there is one,
+		// so that log lines can be correlated across both
+		requestID := r.Header.Get("X-Request-Id")
+
+		if requestID == "" || len(requestID) > 200 {
+			b := make([]byte, 16)
+
+			_, err := rand.Read(b)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+
+			requestID = hex.EncodeToString(b)
+		}
+
+		w.Header().Set("X-Request-Id", requestID)
+		r = app.contextSetRequestID(r, requestID)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (app *application) rateLimit(next http.Handler) http.Handler {
 	type client struct {
 		limiter  *rate.Limiter
@@ -120,3 +151,125 @@ func (app *application) enableCORS(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+func (app *application) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the response varies depending on the Authorization header, so
+		// caches must not serve it for a different key
+		w.Header().Add("Vary", "Authorization")
+
+		authorizationHeader := r.Header.Get("Authorization")
+
+		// requests without an Authorization header are treated as anonymous
+		if authorizationHeader == "" {
+			r = app.contextSetAPIKey(r, data.AnonymousAPIKey)
+			r = app.contextSetUser(r, data.AnonymousUser)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// expect the header to be in the format "Bearer <token>"
+		headerParts := strings.Split(authorizationHeader, " ")
+		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+			app.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
+		plaintext := headerParts[1]
+
+		// user authentication tokens and API keys have different lengths, which
+		// tells us which table to look the bearer token up in
+		v := validator.New()
+
+		if data.ValidateTokenPlaintext(v, plaintext); v.Valid() {
+			user, err := app.models.Users.GetForToken(data.ScopeAuthentication, plaintext)
+			if err != nil {
+				switch {
+				case errors.Is(err, data.ErrRecordNotFound):
+					app.invalidAuthenticationTokenResponse(w, r)
+				default:
+					app.serverErrorResponse(w, r, err)
+				}
+				return
+			}
+
+			r = app.contextSetAPIKey(r, data.AnonymousAPIKey)
+			r = app.contextSetUser(r, user)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		v = validator.New()
+
+		if data.ValidateAPIKeyPlaintext(v, plaintext); !v.Valid() {
+			app.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
+		key, err := app.models.APIKeys.GetForKey(plaintext)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.invalidAuthenticationTokenResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		r = app.contextSetAPIKey(r, key)
+		r = app.contextSetUser(r, data.AnonymousUser)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (app *application) requireAuthenticated(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := app.contextGetAPIKey(r)
+		user := app.contextGetUser(r)
+
+		if key.IsAnonymous() && user.IsAnonymous() {
+			app.authenticationRequiredResponse(w, r)
+			return
+		}
+
+		// user accounts must be activated before they can be used
+		if !user.IsAnonymous() && !user.Activated {
+			app.inactiveAccountResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (app *application) requirePermission(code string, next http.HandlerFunc) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		var (
+			permissions data.Permissions
+			err         error
+		)
+
+		if user := app.contextGetUser(r); !user.IsAnonymous() {
+			permissions, err = app.models.Permissions.GetAllForUser(user.ID)
+		} else {
+			permissions, err = app.models.Permissions.GetAllForAPIKey(app.contextGetAPIKey(r).ID)
+		}
+
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if !permissions.Include(code) {
+			app.notPermittedResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	// the permission check only makes sense for authenticated requests
+	return app.requireAuthenticated(fn)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+func (app *application) createUniversityNameHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Name      string `json:"name"`
+		Kind      string `json:"kind"`
+		ValidFrom int32  `json:"valid_from"`
+		ValidTo   int32  `json:"valid_to"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	_, err = app.models.Universities.Get(universityID, "id")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	name := &data.AlternateName{
+		UniversityID: universityID,
+		Name:         input.Name,
+		Kind:         input.Kind,
+		ValidFrom:    input.ValidFrom,
+		ValidTo:      input.ValidTo,
+	}
+
+	v := validator.New()
+
+	if data.ValidateAlternateName(v, name); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.AlternateNames.Insert(name)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateAlternateName):
+			v.AddError("name", "the university already has this alternate name")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v0/universities/%d/names/%d", universityID, name.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"name": name}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showUniversityNameHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readNameIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	name, err := app.models.AlternateNames.Get(universityID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"name": name}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateUniversityNameHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readNameIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	name, err := app.models.AlternateNames.Get(universityID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// to handle partial updates, we use pointers
+	// to distinguish between a field that was not provided
+	var input struct {
+		Name      *string `json:"name"`
+		Kind      *string `json:"kind"`
+		ValidFrom *int32  `json:"valid_from"`
+		ValidTo   *int32  `json:"valid_to"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Name != nil {
+		name.Name = *input.Name
+	}
+	if input.Kind != nil {
+		name.Kind = *input.Kind
+	}
+	if input.ValidFrom != nil {
+		name.ValidFrom = *input.ValidFrom
+	}
+	if input.ValidTo != nil {
+		name.ValidTo = *input.ValidTo
+	}
+
+	v := validator.New()
+
+	if data.ValidateAlternateName(v, name); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.AlternateNames.Update(name)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateAlternateName):
+			v.AddError("name", "the university already has this alternate name")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"name": name}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteUniversityNameHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readNameIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.AlternateNames.Delete(universityID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "name successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listUniversityNamesHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Universities.Get(universityID, "id")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	names, err := app.models.AlternateNames.GetAllForUniversity(universityID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"names": names}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+)
+
+func (app *application) listRegionsHandler(w http.ResponseWriter, r *http.Request) {
+	regions, err := app.models.Places.GetAllRegions()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"regions": regions}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listRegionProvincesHandler(w http.ResponseWriter, r *http.Request) {
+	code, err := app.readCodeParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	region, err := app.models.Places.GetRegion(code)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	provinces, err := app.models.Places.GetAllProvinces(region.Code)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"region": region, "provinces": provinces}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listRegionCitiesHandler lists every city and municipality in a region,
+// including the ones that don't belong to a province such as those in
+// Metro Manila
+func (app *application) listRegionCitiesHandler(w http.ResponseWriter, r *http.Request) {
+	code, err := app.readCodeParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	region, err := app.models.Places.GetRegion(code)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	cities, err := app.models.Places.GetAllCities(region.Code, "")
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"region": region, "cities": cities}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listProvinceCitiesHandler(w http.ResponseWriter, r *http.Request) {
+	code, err := app.readCodeParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	province, err := app.models.Places.GetProvince(code)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	cities, err := app.models.Places.GetAllCities("", province.Code)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"province": province, "cities": cities}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+func (app *application) createProgramHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Name           string `json:"name"`
+		Level          string `json:"level"`
+		Discipline     string `json:"discipline"`
+		DurationMonths int32  `json:"duration_months"`
+		Status         string `json:"status"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	university, err := app.models.Universities.Get(universityID, "name")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	program := &data.Program{
+		UniversityID:   university.ID,
+		UniversityName: university.Name,
+		Name:           input.Name,
+		Level:          input.Level,
+		Discipline:     input.Discipline,
+		DurationMonths: input.DurationMonths,
+		Status:         input.Status,
+	}
+
+	// programs are assumed to be running unless stated otherwise
+	if program.Status == "" {
+		program.Status = "active"
+	}
+
+	v := validator.New()
+
+	if data.ValidateProgram(v, program); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Programs.Insert(program)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateProgram):
+			v.AddError("name", "the university already offers a program with this name and level")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v0/programs/%d", program.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"program": program}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showProgramHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	program, err := app.models.Programs.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"program": program}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateProgramHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	program, err := app.models.Programs.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// to handle partial updates, we use pointers
+	// to distinguish between a field that was not provided
+	var input struct {
+		Name           *string `json:"name"`
+		Level          *string `json:"level"`
+		Discipline     *string `json:"discipline"`
+		DurationMonths *int32  `json:"duration_months"`
+		Status         *string `json:"status"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Name != nil {
+		program.Name = *input.Name
+	}
+	if input.Level != nil {
+		program.Level = *input.Level
+	}
+	if input.Discipline != nil {
+		program.Discipline = *input.Discipline
+	}
+	if input.DurationMonths != nil {
+		program.DurationMonths = *input.DurationMonths
+	}
+	if input.Status != nil {
+		program.Status = *input.Status
+	}
+
+	v := validator.New()
+
+	if data.ValidateProgram(v, program); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Programs.Update(program)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateProgram):
+			v.AddError("name", "the university already offers a program with this name and level")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"program": program}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteProgramHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Programs.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "program successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listUniversityProgramsHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Universities.Get(universityID, "id")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.listPrograms(w, r, universityID)
+}
+
+func (app *application) listProgramsHandler(w http.ResponseWriter, r *http.Request) {
+	app.listPrograms(w, r, 0)
+}
+
+// listPrograms writes the programs matching the query string, limited to a
+// single university unless universityID is zero
+func (app *application) listPrograms(w http.ResponseWriter, r *http.Request, universityID int64) {
+	var input struct {
+		data.ProgramQuery
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.UniversityID = universityID
+	input.Name = app.readString(qs, "name", "")
+	input.Level = app.readString(qs, "level", "")
+	input.Discipline = app.readString(qs, "discipline", "")
+	input.Status = app.readString(qs, "status", "")
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "id")
+	input.Filters.SortSafelist = []string{"id", "name", "level", "discipline", "-id", "-name", "-level", "-discipline"}
+	input.Filters.Cursor = app.readString(qs, "cursor", "")
+
+	data.ValidateProgramQuery(v, input.ProgramQuery)
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	programs, metadata, err := app.models.Programs.GetAll(input.ProgramQuery, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"programs": programs, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// validateUniversityProgram checks that an optional program id, given by a
+// record that belongs to a university, refers to a program of that
+// university. It returns false if a server error response has already been
+// sent.
+func (app *application) validateUniversityProgram(w http.ResponseWriter, r *http.Request, v *validator.Validator, universityID, programID int64) bool {
+	if programID == 0 {
+		return true
+	}
+
+	program, err := app.models.Programs.Get(programID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("program_id", "must be a program of the university")
+			return true
+		default:
+			app.serverErrorResponse(w, r, err)
+			return false
+		}
+	}
+
+	v.Check(program.UniversityID == universityID, "program_id", "must be a program of the university")
+
+	return true
+}
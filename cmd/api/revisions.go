@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+func (app *application) listUniversityVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-version")
+	input.Filters.SortSafelist = []string{"version", "-version"}
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	revisions, metadata, err := app.models.Revisions.GetAll(id, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// a university without any revisions doesn't exist
+	if len(revisions) == 0 && input.Filters.Page == 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"versions": revisions, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showUniversityVersionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	version, err := app.readVersionParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	revision, err := app.models.Revisions.Get(id, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"version": revision}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) diffUniversityVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		From int
+		To   int
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.From = app.readInt(qs, "from", 0, v)
+	input.To = app.readInt(qs, "to", 0, v)
+
+	v.Check(input.From > 0, "from", "must be greater than zero")
+	v.Check(input.To > 0, "to", "must be greater than zero")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	changes, err := app.models.Revisions.Diff(id, int32(input.From), int32(input.To))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"from": input.From, "to": input.To, "changes": changes}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) revertUniversityHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// ExpectedVersion is the current version of the university as the
+	// client last saw it, so that a revert can't overwrite changes it hasn't
+	// seen
+	var input struct {
+		Version         int32  `json:"version"`
+		ExpectedVersion *int32 `json:"expected_version"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	v.Check(input.Version > 0, "version", "must be greater than zero")
+	v.Check(input.ExpectedVersion != nil, "expected_version", "must be provided")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	university, err := app.models.Universities.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if university.Version != *input.ExpectedVersion {
+		app.editConflictResponse(w, r)
+		return
+	}
+
+	revision, err := app.models.Revisions.Get(id, input.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("version", "no such version of this university")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// the snapshot's fields are applied on top of the current record, so the
+	// update still goes through the version check and is saved as a new version
+	snapshot := revision.University
+	previousImgURL := university.ImgURL
+
+	university.Name = snapshot.Name
+	university.Founded = snapshot.Founded
+	university.Location = snapshot.Location
+	university.Latitude = snapshot.Latitude
+	university.Longitude = snapshot.Longitude
+	university.RegionCode = snapshot.RegionCode
+	university.ProvinceCode = snapshot.ProvinceCode
+	university.CityCode = snapshot.CityCode
+	university.InstitutionType = snapshot.InstitutionType
+	university.Status = snapshot.Status
+	university.ParentID = snapshot.ParentID
+	university.Website = snapshot.Website
+	university.ImgURL = snapshot.ImgURL
+	university.ImgAttribution = snapshot.ImgAttribution
+	university.ImgCite = snapshot.ImgCite
+
+	// campuses are kept as they are, since the snapshot only has their names
+	// and syncing those would delete the campuses added since along with the
+	// details of the ones that were there
+	university.Campuses = nil
+
+	if data.ValidateUniversity(v, university, previousImgURL); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Universities.Update(university, app.auditInfo(r))
+	if err != nil {
+		switch {
+		// the parent may have been deleted or moved under this university
+		// since the revision was made
+		case errors.Is(err, data.ErrUnknownParent), errors.Is(err, data.ErrHierarchyCycle):
+			v.AddError("version", "the parent of this version can no longer be restored")
+			app.failedValidationResponse(w, r, v.Errors)
+		// and the PSGC codes may have been reorganised since
+		case errors.Is(err, data.ErrUnknownRegion), errors.Is(err, data.ErrUnknownProvince), errors.Is(err, data.ErrUnknownCity),
+			errors.Is(err, data.ErrRegionMismatch), errors.Is(err, data.ErrProvinceMismatch):
+			v.AddError("version", "the place of this version can no longer be restored")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// the response says what was left out, so that clients don't take the
+	// campuses to be those of the old version
+	err = app.writeJSON(w, http.StatusOK, envelope{"university": university, "not_reverted": []string{"campuses"}}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -20,12 +20,87 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodGet, "/health", app.healthHandler)
 
 	router.HandlerFunc(http.MethodGet, "/v0/universities", app.listUniversitiesHandler)
+	// also serves lookups by slug and the admin-only GET /v0/universities/trash
 	router.HandlerFunc(http.MethodGet, "/v0/universities/:id", app.showUniversityHandler)
 
 	// restricted access from public
-	router.HandlerFunc(http.MethodPost, "/v0/universities", app.createUniversityHandler)
-	router.HandlerFunc(http.MethodPatch, "/v0/universities/:id", app.updateUniversityHandler)
-	router.HandlerFunc(http.MethodDelete, "/v0/universities/:id", app.deleteUniversityHandler)
+	router.HandlerFunc(http.MethodPost, "/v0/universities", app.requirePermission("universities:create", app.createUniversityHandler))
+	router.HandlerFunc(http.MethodPatch, "/v0/universities/:id", app.requirePermission("universities:write", app.updateUniversityHandler))
+	router.HandlerFunc(http.MethodDelete, "/v0/universities/:id", app.requirePermission("universities:delete", app.deleteUniversityHandler))
+	router.HandlerFunc(http.MethodPost, "/v0/universities/:id/restore", app.requirePermission("universities:delete", app.restoreUniversityHandler))
 
-	return app.recoverPanic(app.enableCORS(app.rateLimit(router)))
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/versions", app.listUniversityVersionsHandler)
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/versions/:version", app.showUniversityVersionHandler)
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/diff", app.diffUniversityVersionsHandler)
+	router.HandlerFunc(http.MethodPost, "/v0/universities/:id/revert", app.requirePermission("universities:write", app.revertUniversityHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/children", app.listUniversityChildrenHandler)
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/ancestors", app.listUniversityAncestorsHandler)
+
+	router.HandlerFunc(http.MethodPost, "/v0/universities/:id/image", app.requirePermission("universities:write", app.uploadUniversityImageHandler))
+	router.HandlerFunc(http.MethodGet, "/v0/images/:id", app.showImageHandler)
+
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/programs", app.listUniversityProgramsHandler)
+	router.HandlerFunc(http.MethodPost, "/v0/universities/:id/programs", app.requirePermission("universities:create", app.createProgramHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/campuses", app.listUniversityCampusesHandler)
+	router.HandlerFunc(http.MethodPost, "/v0/universities/:id/campuses", app.requirePermission("universities:create", app.createCampusHandler))
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/campuses/:campus_id", app.showCampusHandler)
+	router.HandlerFunc(http.MethodPatch, "/v0/universities/:id/campuses/:campus_id", app.requirePermission("universities:write", app.updateCampusHandler))
+	router.HandlerFunc(http.MethodDelete, "/v0/universities/:id/campuses/:campus_id", app.requirePermission("universities:delete", app.deleteCampusHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/names", app.listUniversityNamesHandler)
+	router.HandlerFunc(http.MethodPost, "/v0/universities/:id/names", app.requirePermission("universities:create", app.createUniversityNameHandler))
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/names/:name_id", app.showUniversityNameHandler)
+	router.HandlerFunc(http.MethodPatch, "/v0/universities/:id/names/:name_id", app.requirePermission("universities:write", app.updateUniversityNameHandler))
+	router.HandlerFunc(http.MethodDelete, "/v0/universities/:id/names/:name_id", app.requirePermission("universities:delete", app.deleteUniversityNameHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v0/programs", app.listProgramsHandler)
+	router.HandlerFunc(http.MethodGet, "/v0/programs/:id", app.showProgramHandler)
+	router.HandlerFunc(http.MethodPatch, "/v0/programs/:id", app.requirePermission("universities:write", app.updateProgramHandler))
+	router.HandlerFunc(http.MethodDelete, "/v0/programs/:id", app.requirePermission("universities:delete", app.deleteProgramHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/accreditations", app.listUniversityAccreditationsHandler)
+	router.HandlerFunc(http.MethodPost, "/v0/universities/:id/accreditations", app.requirePermission("universities:create", app.createAccreditationHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v0/accreditations", app.listAccreditationsHandler)
+	router.HandlerFunc(http.MethodGet, "/v0/accreditations/:id", app.showAccreditationHandler)
+	router.HandlerFunc(http.MethodPatch, "/v0/accreditations/:id", app.requirePermission("universities:write", app.updateAccreditationHandler))
+	router.HandlerFunc(http.MethodDelete, "/v0/accreditations/:id", app.requirePermission("universities:delete", app.deleteAccreditationHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/tuition", app.listUniversityTuitionFeesHandler)
+	router.HandlerFunc(http.MethodPost, "/v0/universities/:id/tuition", app.requirePermission("universities:create", app.createTuitionFeeHandler))
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/tuition/series", app.showTuitionSeriesHandler)
+
+	router.HandlerFunc(http.MethodGet, "/v0/tuition", app.listTuitionFeesHandler)
+	router.HandlerFunc(http.MethodGet, "/v0/tuition/:id", app.showTuitionFeeHandler)
+	router.HandlerFunc(http.MethodPatch, "/v0/tuition/:id", app.requirePermission("universities:write", app.updateTuitionFeeHandler))
+	router.HandlerFunc(http.MethodDelete, "/v0/tuition/:id", app.requirePermission("universities:delete", app.deleteTuitionFeeHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/enrollment", app.listUniversityEnrollmentHandler)
+	router.HandlerFunc(http.MethodPost, "/v0/universities/:id/enrollment", app.requirePermission("universities:create", app.createEnrollmentStatHandler))
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/enrollment/:stat_id", app.showEnrollmentStatHandler)
+	router.HandlerFunc(http.MethodPatch, "/v0/universities/:id/enrollment/:stat_id", app.requirePermission("universities:write", app.updateEnrollmentStatHandler))
+	router.HandlerFunc(http.MethodDelete, "/v0/universities/:id/enrollment/:stat_id", app.requirePermission("universities:delete", app.deleteEnrollmentStatHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v0/enrollment", app.listEnrollmentHandler)
+	router.HandlerFunc(http.MethodGet, "/v0/enrollment/regions", app.aggregateEnrollmentByRegionHandler)
+	router.HandlerFunc(http.MethodGet, "/v0/enrollment/institution-types", app.aggregateEnrollmentByInstitutionTypeHandler)
+
+	router.HandlerFunc(http.MethodGet, "/v0/regions", app.listRegionsHandler)
+	router.HandlerFunc(http.MethodGet, "/v0/regions/:code/provinces", app.listRegionProvincesHandler)
+	router.HandlerFunc(http.MethodGet, "/v0/regions/:code/cities", app.listRegionCitiesHandler)
+	router.HandlerFunc(http.MethodGet, "/v0/provinces/:code/cities", app.listProvinceCitiesHandler)
+
+	router.HandlerFunc(http.MethodGet, "/v0/universities/:id/audit", app.requirePermission("audit:read", app.listUniversityAuditHandler))
+	router.HandlerFunc(http.MethodGet, "/v0/audit", app.requirePermission("audit:read", app.listAuditHandler))
+
+	router.HandlerFunc(http.MethodPost, "/v0/users", app.registerUserHandler)
+	router.HandlerFunc(http.MethodPut, "/v0/users/activated", app.activateUserHandler)
+	router.HandlerFunc(http.MethodPut, "/v0/users/password", app.updateUserPasswordHandler)
+
+	router.HandlerFunc(http.MethodPost, "/v0/tokens/authentication", app.createAuthenticationTokenHandler)
+	router.HandlerFunc(http.MethodPost, "/v0/tokens/password-reset", app.createPasswordResetTokenHandler)
+
+	return app.requestID(app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(router)))))
 }
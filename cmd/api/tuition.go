@@ -0,0 +1,330 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+func (app *application) createTuitionFeeHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		ProgramID    int64             `json:"program_id"`
+		AcademicYear data.AcademicYear `json:"academic_year"`
+		Unit         string            `json:"unit"`
+		Amount       *int64            `json:"amount_centavos"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	university, err := app.models.Universities.Get(universityID, "name")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	fee := &data.TuitionFee{
+		UniversityID:   university.ID,
+		UniversityName: university.Name,
+		ProgramID:      input.ProgramID,
+		AcademicYear:   input.AcademicYear,
+		Unit:           input.Unit,
+	}
+
+	v := validator.New()
+
+	// a fee of zero is valid, so a missing amount is told apart with a pointer
+	v.Check(input.Amount != nil, "amount_centavos", "must be provided")
+	if input.Amount != nil {
+		fee.Amount = *input.Amount
+	}
+
+	data.ValidateTuitionFee(v, fee)
+
+	if !app.validateUniversityProgram(w, r, v, fee.UniversityID, fee.ProgramID) {
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Tuition.Insert(fee)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateTuitionFee):
+			v.AddError("academic_year", "a fee for this program, academic year and unit has already been recorded")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v0/tuition/%d", fee.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"tuition_fee": fee}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showTuitionFeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	fee, err := app.models.Tuition.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"tuition_fee": fee}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateTuitionFeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	fee, err := app.models.Tuition.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// to handle partial updates, we use pointers
+	// to distinguish between a field that was not provided
+	var input struct {
+		ProgramID    *int64             `json:"program_id"`
+		AcademicYear *data.AcademicYear `json:"academic_year"`
+		Unit         *string            `json:"unit"`
+		Amount       *int64             `json:"amount_centavos"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.ProgramID != nil {
+		fee.ProgramID = *input.ProgramID
+	}
+	if input.AcademicYear != nil {
+		fee.AcademicYear = *input.AcademicYear
+	}
+	if input.Unit != nil {
+		fee.Unit = *input.Unit
+	}
+	if input.Amount != nil {
+		fee.Amount = *input.Amount
+	}
+
+	v := validator.New()
+
+	data.ValidateTuitionFee(v, fee)
+
+	if !app.validateUniversityProgram(w, r, v, fee.UniversityID, fee.ProgramID) {
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Tuition.Update(fee)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateTuitionFee):
+			v.AddError("academic_year", "a fee for this program, academic year and unit has already been recorded")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"tuition_fee": fee}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteTuitionFeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Tuition.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "tuition fee successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listUniversityTuitionFeesHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Universities.Get(universityID, "id")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.listTuitionFees(w, r, universityID)
+}
+
+func (app *application) listTuitionFeesHandler(w http.ResponseWriter, r *http.Request) {
+	app.listTuitionFees(w, r, 0)
+}
+
+// listTuitionFees writes the fees matching the query string, limited to a
+// single university unless universityID is zero
+func (app *application) listTuitionFees(w http.ResponseWriter, r *http.Request, universityID int64) {
+	var input struct {
+		data.TuitionQuery
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.UniversityID = universityID
+	input.ProgramID = int64(app.readInt(qs, "program_id", 0, v))
+	input.AcademicYear = app.readAcademicYear(qs, "academic_year", v)
+	input.Unit = app.readString(qs, "unit", "")
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-academic_year")
+	input.Filters.SortSafelist = []string{"id", "academic_year", "amount", "-id", "-academic_year", "-amount"}
+	input.Filters.Cursor = app.readString(qs, "cursor", "")
+	input.Filters.SortTypes = map[string]string{"academic_year": "integer", "amount": "bigint"}
+
+	data.ValidateTuitionQuery(v, input.TuitionQuery)
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	fees, metadata, err := app.models.Tuition.GetAll(input.TuitionQuery, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"tuition_fees": fees, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showTuitionSeriesHandler writes the history of each fee of a university,
+// one series per program and unit
+func (app *application) showTuitionSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	universityID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input data.TuitionQuery
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.UniversityID = universityID
+	input.ProgramID = int64(app.readInt(qs, "program_id", 0, v))
+	input.Unit = app.readString(qs, "unit", "")
+
+	if data.ValidateTuitionQuery(v, input); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Universities.Get(universityID, "id")
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	series, err := app.models.Tuition.GetSeries(input)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"series": series}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 
+	"github.com/julienschmidt/httprouter"
 	"github.com/liamgluna/kolehiyo/internal/data"
 	"github.com/liamgluna/kolehiyo/internal/validator"
 )
@@ -13,13 +18,21 @@ func (app *application) createUniversityHandler(w http.ResponseWriter, r *http.R
 	// we decode into an input struct to prevent the client
 	// from providing an id and version key in the request body
 	var input struct {
-		Name     string    `json:"name"`
-		Founded  data.Date `json:"founded"`
-		Location string    `json:"location"`
-		Campuses []string  `json:"campuses"`
-		Website  string    `json:"website"`
-		ImgURL   string    `json:"img_url,omitempty"`
-		ImgCite  string    `json:"img_cite,omitempty"`
+		Name            string                 `json:"name"`
+		Founded         data.Date              `json:"founded"`
+		Location        string                 `json:"location"`
+		Latitude        *float64               `json:"latitude"`
+		Longitude       *float64               `json:"longitude"`
+		RegionCode      string                 `json:"region_code"`
+		ProvinceCode    string                 `json:"province_code"`
+		CityCode        string                 `json:"city_code"`
+		InstitutionType string                 `json:"institution_type"`
+		Status          string                 `json:"status"`
+		ParentID        int64                  `json:"parent_id"`
+		Campuses        []string               `json:"campuses"`
+		Website         string                 `json:"website"`
+		ImgURL          string                 `json:"img_url,omitempty"`
+		ImgAttribution  *data.ImageAttribution `json:"img_attribution,omitempty"`
 	}
 
 	err := app.readJSON(w, r, &input)
@@ -29,25 +42,62 @@ func (app *application) createUniversityHandler(w http.ResponseWriter, r *http.R
 	}
 
 	university := &data.University{
-		Name:     input.Name,
-		Founded:  input.Founded,
-		Location: input.Location,
-		Campuses: input.Campuses,
-		Website:  input.Website,
-		ImgURL:   input.ImgURL,
-		ImgCite:  input.ImgCite,
+		Name:            input.Name,
+		Founded:         input.Founded,
+		Location:        input.Location,
+		Latitude:        input.Latitude,
+		Longitude:       input.Longitude,
+		RegionCode:      input.RegionCode,
+		ProvinceCode:    input.ProvinceCode,
+		CityCode:        input.CityCode,
+		InstitutionType: input.InstitutionType,
+		Status:          input.Status,
+		ParentID:        input.ParentID,
+		Campuses:        input.Campuses,
+		Website:         input.Website,
+		ImgURL:          input.ImgURL,
+		ImgAttribution:  input.ImgAttribution,
+	}
+
+	// universities are assumed to be operating unless stated otherwise
+	if university.Status == "" {
+		university.Status = "active"
 	}
 
 	v := validator.New()
 
-	if data.ValidateUniversity(v, university); !v.Valid() {
+	if data.ValidateUniversity(v, university, ""); !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	err = app.models.Universities.Insert(university)
+	err = app.models.Universities.Insert(university, app.auditInfo(r))
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrUnknownRegion):
+			v.AddError("region_code", "must be an existing region")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrUnknownProvince):
+			v.AddError("province_code", "must be an existing province")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrUnknownCity):
+			v.AddError("city_code", "must be an existing city or municipality")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrRegionMismatch):
+			v.AddError("region_code", "does not contain province_code or city_code")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrProvinceMismatch):
+			v.AddError("province_code", "does not contain city_code")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrUnknownParent):
+			v.AddError("parent_id", "must be an existing university")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrHierarchyCycle):
+			v.AddError("parent_id", "must not be a constituent unit of the university")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -61,13 +111,64 @@ func (app *application) createUniversityHandler(w http.ResponseWriter, r *http.R
 }
 
 func (app *application) showUniversityHandler(w http.ResponseWriter, r *http.Request) {
+	// httprouter doesn't allow a static segment to sit alongside the :id
+	// wildcard, so the trash listing is dispatched from here
+	if httprouter.ParamsFromContext(r.Context()).ByName("id") == "trash" {
+		app.requirePermission("universities:admin", app.listTrashedUniversitiesHandler)(w, r)
+		return
+	}
+
 	id, err := app.readIDParam(r)
 	if err != nil {
-		app.notFoundResponse(w, r)
+		// the university is looked up by its slug instead, and requests for
+		// outdated slugs are sent on to the current one
+		slug := httprouter.ParamsFromContext(r.Context()).ByName("id")
+		if !validator.Matches(slug, data.SlugRX) {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		var current string
+
+		id, current, err = app.models.Universities.ResolveSlug(slug)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		if current != slug {
+			location := url.URL{Path: "/v0/universities/" + current, RawQuery: r.URL.RawQuery}
+			http.Redirect(w, r, location.String(), http.StatusMovedPermanently)
+			return
+		}
+	}
+
+	var input struct {
+		Fields  []string
+		Include []string
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Fields = app.readCSV(qs, "fields", []string{})
+	input.Include = app.readCSV(qs, "include", []string{})
+
+	data.ValidateFieldset(v, "fields", input.Fields, data.UniversityFields)
+	data.ValidateFieldset(v, "include", input.Include, data.UniversityIncludes)
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	university, err := app.models.Universities.Get(id)
+	university, err := app.models.Universities.Get(id, input.Fields...)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -78,11 +179,16 @@ func (app *application) showUniversityHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"university": university}, nil)
+	shaped, err := app.shapeUniversities([]*data.University{university}, input.Fields, input.Include)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
+		return
 	}
 
+	err = app.writeJSON(w, http.StatusOK, envelope{"university": shaped[0]}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
 }
 
 func (app *application) updateUniversityHandler(w http.ResponseWriter, r *http.Request) {
@@ -106,13 +212,21 @@ func (app *application) updateUniversityHandler(w http.ResponseWriter, r *http.R
 	// to handle partial updates, we use pointers
 	// to distinguish between a field that was not provided
 	var input struct {
-		Name     *string    `json:"name"`
-		Founded  *data.Date `json:"founded"`
-		Location *string    `json:"location"`
-		Campuses []string   `json:"campuses"`
-		Website  *string    `json:"website"`
-		ImgURL   *string    `json:"img_url,omitempty"`
-		ImgCite  *string    `json:"img_cite,omitempty"`
+		Name            *string                `json:"name"`
+		Founded         *data.Date             `json:"founded"`
+		Location        *string                `json:"location"`
+		Latitude        *float64               `json:"latitude"`
+		Longitude       *float64               `json:"longitude"`
+		RegionCode      *string                `json:"region_code"`
+		ProvinceCode    *string                `json:"province_code"`
+		CityCode        *string                `json:"city_code"`
+		InstitutionType *string                `json:"institution_type"`
+		Status          *string                `json:"status"`
+		ParentID        *int64                 `json:"parent_id"`
+		Campuses        []string               `json:"campuses"`
+		Website         *string                `json:"website"`
+		ImgURL          *string                `json:"img_url,omitempty"`
+		ImgAttribution  *data.ImageAttribution `json:"img_attribution,omitempty"`
 	}
 
 	err = app.readJSON(w, r, &input)
@@ -121,6 +235,9 @@ func (app *application) updateUniversityHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	// the image is checked against the one being replaced
+	previousImgURL := university.ImgURL
+
 	// if the input field is nil, we don't update the field
 	if input.Name != nil {
 		university.Name = *input.Name
@@ -131,30 +248,88 @@ func (app *application) updateUniversityHandler(w http.ResponseWriter, r *http.R
 	if input.Location != nil {
 		university.Location = *input.Location
 	}
-	if input.Campuses != nil {
-		university.Campuses = input.Campuses
+	if input.Latitude != nil {
+		university.Latitude = input.Latitude
+	}
+	if input.Longitude != nil {
+		university.Longitude = input.Longitude
+	}
+	// a broader place replaces the narrower ones unless they are given too,
+	// since they would no longer be within it, and a narrower place given on
+	// its own replaces the broader ones, which are then looked up from it
+	if input.RegionCode != nil {
+		university.RegionCode, university.ProvinceCode, university.CityCode = *input.RegionCode, "", ""
+	}
+	if input.ProvinceCode != nil {
+		university.ProvinceCode, university.CityCode = *input.ProvinceCode, ""
+		if input.RegionCode == nil {
+			university.RegionCode = ""
+		}
+	}
+	if input.CityCode != nil {
+		university.CityCode = *input.CityCode
+		if input.RegionCode == nil && input.ProvinceCode == nil {
+			university.RegionCode, university.ProvinceCode = "", ""
+		}
+	}
+	if input.InstitutionType != nil {
+		university.InstitutionType = *input.InstitutionType
+	}
+	if input.Status != nil {
+		university.Status = *input.Status
 	}
+	if input.ParentID != nil {
+		university.ParentID = *input.ParentID
+	}
+	// campuses that weren't sent are left nil, so that Update keeps the
+	// current ones instead of the list fetched above
+	university.Campuses = input.Campuses
 	if input.Website != nil {
 		university.Website = *input.Website
 	}
 	if input.ImgURL != nil {
+		// a new image needs its own attribution rather than the one of the
+		// image it replaces
+		if *input.ImgURL != university.ImgURL {
+			university.ImgAttribution = nil
+		}
 		university.ImgURL = *input.ImgURL
 	}
-	if input.ImgCite != nil {
-		university.ImgCite = *input.ImgCite
+	if input.ImgAttribution != nil {
+		university.ImgAttribution = input.ImgAttribution
 	}
-	
 
 	v := validator.New()
 
-	if data.ValidateUniversity(v, university); !v.Valid() {
+	if data.ValidateUniversity(v, university, previousImgURL); !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	err = app.models.Universities.Update(university)
+	err = app.models.Universities.Update(university, app.auditInfo(r))
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrUnknownRegion):
+			v.AddError("region_code", "must be an existing region")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrUnknownProvince):
+			v.AddError("province_code", "must be an existing province")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrUnknownCity):
+			v.AddError("city_code", "must be an existing city or municipality")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrRegionMismatch):
+			v.AddError("region_code", "does not contain province_code or city_code")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrProvinceMismatch):
+			v.AddError("province_code", "does not contain city_code")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrUnknownParent):
+			v.AddError("parent_id", "must be an existing university")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrHierarchyCycle):
+			v.AddError("parent_id", "must not be a constituent unit of the university")
+			app.failedValidationResponse(w, r, v.Errors)
 		case errors.Is(err, data.ErrEditConflict):
 			app.editConflictResponse(w, r)
 		default:
@@ -176,7 +351,7 @@ func (app *application) deleteUniversityHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	err = app.models.Universities.Delete(id)
+	err = app.models.Universities.Delete(id, app.auditInfo(r))
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -193,9 +368,65 @@ func (app *application) deleteUniversityHandler(w http.ResponseWriter, r *http.R
 	}
 }
 
+func (app *application) restoreUniversityHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	university, err := app.models.Universities.Restore(id, app.auditInfo(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"university": university}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listTrashedUniversitiesHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-deleted_at")
+	input.Filters.SortSafelist = []string{"id", "name", "deleted_at", "-id", "-name", "-deleted_at"}
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	universities, metadata, err := app.models.Universities.GetTrash(input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"universities": universities, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
 func (app *application) listUniversitiesHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Name string
+		data.UniversityQuery
+		Include []string
 		data.Filters
 	}
 
@@ -203,25 +434,139 @@ func (app *application) listUniversitiesHandler(w http.ResponseWriter, r *http.R
 
 	qs := r.URL.Query()
 
+	input.Fields = app.readCSV(qs, "fields", []string{})
+	input.Include = app.readCSV(qs, "include", []string{})
 	input.Name = app.readString(qs, "name", "")
+	input.SearchMode = app.readString(qs, "search_mode", data.SearchModeFullText)
+	input.Location = app.readString(qs, "location", "")
+	input.Campus = app.readString(qs, "campus", "")
+	input.FoundedFrom = app.readDate(qs, "founded_from", v)
+	input.FoundedTo = app.readDate(qs, "founded_to", v)
+	input.Near = app.readCoordinates(qs, "near", v)
+	input.RadiusKm = app.readFloat(qs, "radius_km", 10, v)
+	input.RegionCode = app.readString(qs, "region", "")
+	input.ProvinceCode = app.readString(qs, "province", "")
+	input.CityCode = app.readString(qs, "city", "")
+	input.InstitutionType = app.readString(qs, "institution_type", "")
+	input.Status = app.readString(qs, "status", "")
+	input.IncludeChildren = app.readBool(qs, "include_children", false, v)
 	input.Filters.Page = app.readInt(qs, "page", 1, v)
 	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
-	input.Filters.Sort = app.readString(qs, "sort", "id")
-	input.Filters.SortSafelist = []string{"id", "name", "founded", "-id", "-name", "-founded"}
+	input.Filters.SortSafelist = []string{"id", "name", "location", "founded", "relevance", "distance", "tuition", "-id", "-name", "-location", "-founded", "-distance", "-tuition"}
+	input.Filters.Cursor = app.readString(qs, "cursor", "")
+	input.Filters.SortTypes = map[string]string{"relevance": "double precision", "distance": "double precision", "tuition": "bigint"}
+
+	// a near search lists the closest universities first unless told otherwise
+	if input.Near != nil {
+		input.Filters.Sort = app.readString(qs, "sort", "distance")
+	} else {
+		input.Filters.Sort = app.readString(qs, "sort", "id")
+	}
+
+	data.ValidateUniversityQuery(v, input.UniversityQuery)
+
+	for _, sort := range strings.Split(input.Filters.Sort, ",") {
+		v.Check(input.Near != nil || strings.TrimPrefix(sort, "-") != "distance", "sort", "distance can only be used together with near")
+	}
+	data.ValidateFieldset(v, "include", input.Include, data.UniversityIncludes)
 
 	if data.ValidateFilters(v, input.Filters); !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	universities, metadata, err := app.models.Universities.GetAll(input.Name, input.Filters)
+	universities, metadata, err := app.models.Universities.GetAll(input.UniversityQuery, input.Filters)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"universities": universities, "metadata": metadata}, nil)
+	shaped, err := app.shapeUniversities(universities, input.Fields, input.Include)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"universities": shaped, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+// shapeUniversities restricts each university to the requested sparse
+// fieldset and embeds the requested related collections. Without either,
+// the universities are returned as they are.
+func (app *application) shapeUniversities(universities []*data.University, fields, include []string) ([]any, error) {
+	shaped := make([]any, len(universities))
+
+	if len(fields) == 0 && len(include) == 0 {
+		for i, university := range universities {
+			shaped[i] = university
+		}
+		return shaped, nil
+	}
+
+	ids := make([]int64, len(universities))
+	for i, university := range universities {
+		ids[i] = university.ID
+	}
+
+	// each collection is loaded for all of the universities at once
+	embedded := make(map[string]func(id int64) any)
+
+	for _, name := range include {
+		switch name {
+		case "names":
+			names, err := app.models.AlternateNames.GetAllForUniversities(ids)
+			if err != nil {
+				return nil, err
+			}
+			embedded[name] = func(id int64) any { return names[id] }
+		case "programs":
+			programs, err := app.models.Programs.GetAllForUniversities(ids)
+			if err != nil {
+				return nil, err
+			}
+			embedded[name] = func(id int64) any { return programs[id] }
+		case "versions":
+			revisions, err := app.models.Revisions.GetAllForUniversities(ids)
+			if err != nil {
+				return nil, err
+			}
+			embedded[name] = func(id int64) any { return revisions[id] }
+		}
+	}
+
+	for i, university := range universities {
+		js, err := json.Marshal(university)
+		if err != nil {
+			return nil, err
+		}
+
+		// decoding into raw messages keeps the values exactly as encoded
+		var encoded map[string]json.RawMessage
+
+		err = json.Unmarshal(js, &encoded)
+		if err != nil {
+			return nil, err
+		}
+
+		object := make(map[string]any, len(encoded)+len(embedded))
+
+		for key, value := range encoded {
+			// search scores and distances aren't fields that can be requested,
+			// so they are kept whenever the search computed them
+			if len(fields) == 0 || slices.Contains(fields, key) || key == "score" || key == "distance_km" {
+				object[key] = value
+			}
+		}
+
+		for name, get := range embedded {
+			object[name] = get(university.ID)
+		}
+
+		shaped[i] = object
+	}
+
+	return shaped, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+	_ "github.com/lib/pq"
+)
+
+// apikey is a small command line tool for issuing and revoking the API keys
+// used to access the restricted routes of cmd/api
+func main() {
+	var (
+		dsn         string
+		owner       string
+		permissions []string
+		revoke      int64
+	)
+
+	flag.StringVar(&dsn, "db-dsn", "", "PostgreSQL DSN")
+	flag.StringVar(&owner, "owner", "", "Owner of the new API key")
+	flag.Func("permissions", "Permission codes granted to the new API key (comma separated)", func(val string) error {
+		permissions = strings.Split(val, ",")
+		return nil
+	})
+	flag.Int64Var(&revoke, "revoke", 0, "ID of the API key to revoke")
+
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	db, err := openDB(dsn)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	models := data.NewModels(db)
+
+	if revoke != 0 {
+		err = models.APIKeys.Revoke(revoke)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Printf("API key %d revoked\n", revoke)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateAPIKey(v, &data.APIKey{Owner: owner}); !v.Valid() {
+		for key, message := range v.Errors {
+			logger.Error(fmt.Sprintf("%s %s", key, message))
+		}
+		os.Exit(1)
+	}
+
+	key, err := models.APIKeys.New(owner, permissions...)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	// the plaintext key cannot be recovered from the database, so this is
+	// the only time it is shown
+	fmt.Printf("ID:\t%d\nOwner:\t%s\nPermissions:\t%s\nKey:\t%s\n", key.ID, key.Owner, strings.Join(permissions, ","), key.Plaintext)
+}
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
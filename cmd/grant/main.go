@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+	_ "github.com/lib/pq"
+)
+
+// grant is a small command line tool for granting permission codes to user
+// accounts, which is how users get access to the restricted routes of cmd/api
+func main() {
+	var (
+		dsn         string
+		email       string
+		permissions []string
+	)
+
+	flag.StringVar(&dsn, "db-dsn", "", "PostgreSQL DSN")
+	flag.StringVar(&email, "email", "", "Email address of the user to grant the permissions to")
+	flag.Func("permissions", "Permission codes granted to the user (comma separated)", func(val string) error {
+		permissions = strings.Split(val, ",")
+		return nil
+	})
+
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	v := validator.New()
+
+	data.ValidateEmail(v, email)
+	v.Check(len(permissions) > 0, "permissions", "must be provided")
+
+	if !v.Valid() {
+		for key, message := range v.Errors {
+			logger.Error(fmt.Sprintf("%s %s", key, message))
+		}
+		os.Exit(1)
+	}
+
+	db, err := openDB(dsn)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	models := data.NewModels(db)
+
+	user, err := models.Users.GetByEmail(email)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			logger.Error("no user found with this email address", "email", email)
+		default:
+			logger.Error(err.Error())
+		}
+		os.Exit(1)
+	}
+
+	err = models.Permissions.AddForUser(user.ID, permissions...)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Printf("ID:\t%d\nEmail:\t%s\nGranted:\t%s\n", user.ID, user.Email, strings.Join(permissions, ","))
+}
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/data"
+	"github.com/liamgluna/kolehiyo/internal/validator"
+	_ "github.com/lib/pq"
+)
+
+// psgc loads regions, provinces, cities and municipalities into the database
+// from the Philippine Standard Geographic Code publication of the Philippine
+// Statistics Authority, saved as CSV. It can be rerun whenever a new edition
+// is published, since existing places are updated in place.
+func main() {
+	var (
+		dsn  string
+		file string
+	)
+
+	flag.StringVar(&dsn, "db-dsn", "", "PostgreSQL DSN")
+	flag.StringVar(&file, "file", "", "Path to the PSGC publication saved as CSV")
+
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	f, err := os.Open(file)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	regions, provinces, cities, err := readPSGC(f)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	db, err := openDB(dsn)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	models := data.NewModels(db)
+
+	err = models.Places.Import(regions, provinces, cities)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	logger.Info("imported PSGC", "regions", len(regions), "provinces", len(provinces), "cities", len(cities))
+}
+
+// readPSGC reads the places out of the CSV. Only the code, name and
+// geographic level columns are used, and they are found by their headers.
+// A place's region and province are worked out from the digits of its code.
+func readPSGC(r io.Reader) ([]*data.Region, []*data.Province, []*data.City, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	codeColumn, ok1 := columns["10-digit PSGC"]
+	nameColumn, ok2 := columns["Name"]
+	levelColumn, ok3 := columns["Geographic Level"]
+
+	if !ok1 || !ok2 || !ok3 {
+		return nil, nil, nil, errors.New(`the CSV must have "10-digit PSGC", "Name" and "Geographic Level" columns`)
+	}
+
+	var (
+		regions   []*data.Region
+		provinces []*data.Province
+		cities    []*data.City
+	)
+
+	isProvince := make(map[string]bool)
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, record := range records {
+		if len(record) <= max(codeColumn, nameColumn, levelColumn) {
+			continue
+		}
+
+		code := strings.TrimSpace(record[codeColumn])
+		name := strings.TrimSpace(record[nameColumn])
+
+		// spreadsheets drop the leading zero of the codes of the first regions
+		if len(code) == 9 {
+			code = "0" + code
+		}
+
+		if !validator.Matches(code, data.PSGCRX) {
+			continue
+		}
+
+		regionCode := code[:2] + "00000000"
+		provinceCode := code[:5] + "00000"
+
+		switch strings.TrimSpace(record[levelColumn]) {
+		case "Reg":
+			regions = append(regions, &data.Region{Code: code, Name: name})
+		case "Prov":
+			provinces = append(provinces, &data.Province{Code: code, RegionCode: regionCode, Name: name})
+			isProvince[code] = true
+		case "City":
+			cities = append(cities, &data.City{Code: code, RegionCode: regionCode, ProvinceCode: provinceCode, Name: name, Kind: "city"})
+		case "Mun":
+			cities = append(cities, &data.City{Code: code, RegionCode: regionCode, ProvinceCode: provinceCode, Name: name, Kind: "municipality"})
+		}
+	}
+
+	// highly urbanized cities and the cities of Metro Manila have codes
+	// of their own in the province digits, so they have no province
+	for _, city := range cities {
+		if !isProvince[city.ProvinceCode] {
+			city.ProvinceCode = ""
+		}
+	}
+
+	if len(regions) == 0 {
+		return nil, nil, nil, errors.New("no regions found in the CSV")
+	}
+
+	return regions, provinces, cities, nil
+}
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
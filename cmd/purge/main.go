@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/blob"
+	"github.com/liamgluna/kolehiyo/internal/data"
+	_ "github.com/lib/pq"
+)
+
+// purge permanently deletes universities that have been in the trash for
+// longer than the retention period, along with their uploaded images. It is
+// meant to be run periodically, for example from a systemd timer or cron job.
+func main() {
+	var (
+		dsn       string
+		retention time.Duration
+		imagesDir string
+	)
+
+	flag.StringVar(&dsn, "db-dsn", "", "PostgreSQL DSN")
+	flag.DurationVar(&retention, "retention", 30*24*time.Hour, "How long deleted universities are kept in the trash")
+	flag.StringVar(&imagesDir, "images-dir", "./uploads", "Directory uploaded images are stored in")
+
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	if retention < 0 {
+		logger.Error("retention must not be negative")
+		os.Exit(1)
+	}
+
+	db, err := openDB(dsn)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	blobs, err := blob.NewLocal(imagesDir)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	models := data.NewModels(db)
+
+	purged, imageIDs, err := models.Universities.Purge(retention, data.AuditInfo{Actor: "system:purge"})
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	logger.Info("purged trashed universities", "count", purged, "retention", retention.String())
+
+	// the images are already gone from the database, so a file that can't
+	// be removed is only logged rather than stopping the rest
+	for _, id := range imageIDs {
+		img := &data.Image{ID: id}
+
+		for _, size := range data.ImageSizes {
+			err := blobs.Delete(img.Key(size))
+			if err != nil {
+				logger.Error(err.Error(), "image_id", id)
+			}
+		}
+	}
+
+	logger.Info("removed purged images", "count", len(imageIDs))
+}
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
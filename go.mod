@@ -5,6 +5,8 @@ go 1.22.0
 require (
 	github.com/julienschmidt/httprouter v1.3.0
 	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.22.0
+	golang.org/x/image v0.18.0
 	golang.org/x/time v0.5.0
 )
 
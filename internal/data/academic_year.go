@@ -0,0 +1,69 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+var ErrInvalidAcademicYearFormat = errors.New("invalid academic year format")
+
+// AcademicYear is a school year, stored as the calendar year it starts in and
+// encoded in JSON as "2024-2025"
+type AcademicYear int32
+
+func (y AcademicYear) String() string {
+	return fmt.Sprintf("%d-%d", y, y+1)
+}
+
+func (y AcademicYear) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(y.String())), nil
+}
+
+func (y *AcademicYear) UnmarshalJSON(jsonValue []byte) error {
+	unquotedJSONValue, err := strconv.Unquote(string(jsonValue))
+	if err != nil {
+		return ErrInvalidAcademicYearFormat
+	}
+
+	*y, err = ParseAcademicYear(unquotedJSONValue)
+
+	return err
+}
+
+// ParseAcademicYear reads an academic year written as two consecutive
+// calendar years, such as "2024-2025"
+func ParseAcademicYear(s string) (AcademicYear, error) {
+	var start, end int32
+
+	n, err := fmt.Sscanf(s, "%4d-%4d", &start, &end)
+	if err != nil || n != 2 || len(s) != 9 || end != start+1 {
+		return 0, ErrInvalidAcademicYearFormat
+	}
+
+	return AcademicYear(start), nil
+}
+
+// ValidateAcademicYear checks an academic year that must be provided
+func ValidateAcademicYear(v *validator.Validator, key string, y AcademicYear) {
+	v.Check(y != 0, key, "must be provided")
+	v.Check(y == 0 || y >= 1900, key, "must not start before 1900")
+	v.Check(int(y) <= time.Now().Year()+1, key, "must not be more than a year in the future")
+}
+
+// percentChange returns the change from one academic year's value to the
+// next as a percentage rounded to two decimal places, or nil when the years
+// aren't consecutive or there is nothing to compare against
+func percentChange(prevYear AcademicYear, prev int64, year AcademicYear, value int64) *float64 {
+	if prevYear != year-1 || prev == 0 {
+		return nil
+	}
+
+	change := math.Round(float64(value-prev)/float64(prev)*10000) / 100
+
+	return &change
+}
@@ -0,0 +1,81 @@
+package data
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAcademicYear(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    AcademicYear
+		wantErr error
+	}{
+		{"2024-2025", 2024, nil},
+		{"1908-1909", 1908, nil},
+		{"", 0, ErrInvalidAcademicYearFormat},
+		{"2024", 0, ErrInvalidAcademicYearFormat},
+		{"2024-2026", 0, ErrInvalidAcademicYearFormat},
+		{"2025-2024", 0, ErrInvalidAcademicYearFormat},
+		{"2024-25", 0, ErrInvalidAcademicYearFormat},
+		{"2024/2025", 0, ErrInvalidAcademicYearFormat},
+		{" 2024-2025", 0, ErrInvalidAcademicYearFormat},
+		{"2024-2025x", 0, ErrInvalidAcademicYearFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseAcademicYear(tt.input)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseAcademicYear(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseAcademicYear(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+
+			// a parsed academic year is written back the way it was given
+			if err == nil && got.String() != tt.input {
+				t.Errorf("ParseAcademicYear(%q).String() = %q", tt.input, got.String())
+			}
+		})
+	}
+}
+
+func TestPercentChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		prevYear AcademicYear
+		prev     int64
+		year     AcademicYear
+		value    int64
+		want     *float64
+	}{
+		{"increase", 2023, 1000, 2024, 1250, ptrTo(25.0)},
+		{"decrease", 2023, 1000, 2024, 750, ptrTo(-25.0)},
+		{"no change", 2023, 1000, 2024, 1000, ptrTo(0.0)},
+		{"rounded to two decimal places", 2023, 3, 2024, 4, ptrTo(33.33)},
+		{"years apart", 2022, 1000, 2024, 1250, nil},
+		{"same year", 2024, 1000, 2024, 1250, nil},
+		{"nothing before", 2023, 0, 2024, 1250, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := percentChange(tt.prevYear, tt.prev, tt.year, tt.value)
+
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil:
+				t.Errorf("percentChange = %v, want %v", got, tt.want)
+			case *got != *tt.want:
+				t.Errorf("percentChange = %g, want %g", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
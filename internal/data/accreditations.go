@@ -0,0 +1,346 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+	"github.com/lib/pq"
+)
+
+const (
+	AccreditationKindAccreditation = "accreditation"
+	// CHED designates Centers of Excellence and Centers of Development per discipline
+	AccreditationKindCOE = "center_of_excellence"
+	AccreditationKindCOD = "center_of_development"
+)
+
+var AccreditationKinds = []string{AccreditationKindAccreditation, AccreditationKindCOE, AccreditationKindCOD}
+
+// AccreditingBodies are the accrediting agencies recognized in the country.
+// CHED only makes COE and COD designations.
+var AccreditingBodies = []string{"PAASCU", "PACUCOA", "ACSCU-AAI", "ALCUCOA", "AACCUP", "CHED"}
+
+// AccreditationStatuses are computed from the validity dates
+var AccreditationStatuses = []string{"pending", "active", "expired"}
+
+// accreditationStatus works out the status of an accreditation on the
+// current date. Records without an end date stay active. The validity
+// columns are left unqualified, since no joined table shares their names.
+const accreditationStatus = `CASE WHEN valid_from > CURRENT_DATE THEN 'pending' WHEN valid_to < CURRENT_DATE THEN 'expired' ELSE 'active' END`
+
+type AccreditationModel struct {
+	DB *sql.DB
+}
+
+type Accreditation struct {
+	ID             int64     `json:"id"`
+	CreatedAt      time.Time `json:"-"`
+	UniversityID   int64     `json:"university_id"`
+	UniversityName string    `json:"university_name,omitempty"`
+	// ProgramID is set when the accreditation covers a single program
+	ProgramID int64  `json:"program_id,omitempty"`
+	Kind      string `json:"kind"`
+	Body      string `json:"body"`
+	// Level is the accreditation level from I to IV, and is zero for
+	// COE and COD designations
+	Level     int32  `json:"level,omitempty"`
+	Scope     string `json:"scope"`
+	ValidFrom Day    `json:"valid_from"`
+	ValidTo   *Day   `json:"valid_to,omitempty"`
+	Status    string `json:"status"`
+	Version   int32  `json:"version"`
+}
+
+func ValidateAccreditation(v *validator.Validator, accreditation *Accreditation) {
+	v.Check(validator.PermittedValue(accreditation.Kind, AccreditationKinds...), "kind", "must be one of "+strings.Join(AccreditationKinds, ", "))
+
+	v.Check(validator.PermittedValue(accreditation.Body, AccreditingBodies...), "body", "must be one of "+strings.Join(AccreditingBodies, ", "))
+
+	if accreditation.Kind == AccreditationKindAccreditation {
+		v.Check(accreditation.Body != "CHED", "body", "must be an accrediting agency")
+		v.Check(accreditation.Level >= 1 && accreditation.Level <= 4, "level", "must be between 1 and 4")
+	} else {
+		v.Check(accreditation.Body == "CHED", "body", "must be CHED for COE and COD designations")
+		v.Check(accreditation.Level == 0, "level", "must not be provided for COE and COD designations")
+	}
+
+	v.Check(accreditation.Scope != "", "scope", "must be provided")
+	v.Check(len(accreditation.Scope) <= 300, "scope", "must not be more than 300 bytes long")
+
+	v.Check(!time.Time(accreditation.ValidFrom).IsZero(), "valid_from", "must be provided")
+
+	if accreditation.ValidTo != nil {
+		v.Check(!time.Time(*accreditation.ValidTo).Before(time.Time(accreditation.ValidFrom)), "valid_to", "must not be before valid_from")
+	}
+}
+
+// AccreditationQuery holds the filters that narrow down the accreditations
+// returned by GetAll. Zero values leave the corresponding filter unapplied.
+type AccreditationQuery struct {
+	UniversityID int64
+	Kind         string
+	Body         string
+	Level        int
+	Scope        string
+	Status       string
+}
+
+func ValidateAccreditationQuery(v *validator.Validator, q AccreditationQuery) {
+	if q.Kind != "" {
+		v.Check(validator.PermittedValue(q.Kind, AccreditationKinds...), "kind", "must be one of "+strings.Join(AccreditationKinds, ", "))
+	}
+
+	if q.Body != "" {
+		v.Check(validator.PermittedValue(q.Body, AccreditingBodies...), "body", "must be one of "+strings.Join(AccreditingBodies, ", "))
+	}
+
+	// zero is the default and leaves the level unfiltered
+	if q.Level != 0 {
+		v.Check(q.Level >= 1 && q.Level <= 4, "level", "must be between 1 and 4")
+	}
+
+	v.Check(len(q.Scope) <= 300, "scope", "must not be more than 300 bytes long")
+
+	if q.Status != "" {
+		v.Check(validator.PermittedValue(q.Status, AccreditationStatuses...), "status", "must be one of "+strings.Join(AccreditationStatuses, ", "))
+	}
+}
+
+func (m AccreditationModel) Insert(accreditation *Accreditation) error {
+	query := fmt.Sprintf(`
+		INSERT INTO accreditations (university_id, program_id, kind, body, level, scope, valid_from, valid_to)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, %s, version`, accreditationStatus)
+
+	args := []any{
+		accreditation.UniversityID,
+		nullInt64(accreditation.ProgramID),
+		accreditation.Kind,
+		accreditation.Body,
+		accreditation.Level,
+		accreditation.Scope,
+		accreditation.ValidFrom,
+		nullDay(accreditation.ValidTo)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&accreditation.ID, &accreditation.CreatedAt, &accreditation.Status, &accreditation.Version)
+}
+
+// Get returns the accreditation with the given id, as long as its
+// university hasn't been deleted
+func (m AccreditationModel) Get(id int64) (*Accreditation, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := fmt.Sprintf(`
+		SELECT a.id, a.created_at, a.university_id, u.name, COALESCE(a.program_id, 0), a.kind, a.body, a.level, a.scope, a.valid_from, a.valid_to, %s, a.version
+		FROM accreditations a
+		INNER JOIN universities u ON u.id = a.university_id
+		WHERE a.id = $1 AND u.deleted_at IS NULL`, accreditationStatus)
+
+	var accreditation Accreditation
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&accreditation.ID,
+		&accreditation.CreatedAt,
+		&accreditation.UniversityID,
+		&accreditation.UniversityName,
+		&accreditation.ProgramID,
+		&accreditation.Kind,
+		&accreditation.Body,
+		&accreditation.Level,
+		&accreditation.Scope,
+		&accreditation.ValidFrom,
+		&accreditation.ValidTo,
+		&accreditation.Status,
+		&accreditation.Version)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &accreditation, nil
+}
+
+func (m AccreditationModel) Update(accreditation *Accreditation) error {
+	// version is used to implement optimistic concurrency control
+	query := fmt.Sprintf(`
+		UPDATE accreditations
+		SET program_id = $1, kind = $2, body = $3, level = $4, scope = $5, valid_from = $6, valid_to = $7, version = version + 1
+		WHERE id = $8 AND version = $9
+		RETURNING %s, version`, accreditationStatus)
+
+	args := []any{
+		nullInt64(accreditation.ProgramID),
+		accreditation.Kind,
+		accreditation.Body,
+		accreditation.Level,
+		accreditation.Scope,
+		accreditation.ValidFrom,
+		nullDay(accreditation.ValidTo),
+		accreditation.ID,
+		accreditation.Version}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&accreditation.Status, &accreditation.Version)
+	if err != nil {
+		switch {
+		// sql.ErrNoRows in this case means that there was an edit conflict
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m AccreditationModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM accreditations
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAll returns the accreditations matching the query across every
+// university that hasn't been deleted
+func (m AccreditationModel) GetAll(q AccreditationQuery, filters Filters) ([]*Accreditation, Metadata, error) {
+	sortFields := filters.sortFields()
+	sortKeys := make([]string, len(sortFields))
+
+	for i, field := range sortFields {
+		sortKeys[i] = fmt.Sprintf("(%s)::text", field.column)
+	}
+
+	keysetCond, orderBy, keysetArgs := filters.keyset(sortFields, 9)
+
+	// the join and the status happen in a subquery so that the filters and
+	// keyset clauses can refer to them without qualifying them
+	query := fmt.Sprintf(`
+	SELECT count(*) OVER(), id, created_at, university_id, university_name, COALESCE(program_id, 0), kind, body, level, scope, valid_from, valid_to, status, version, ARRAY[%s]
+	FROM (
+		SELECT a.*, u.name AS university_name, %s AS status
+		FROM accreditations a
+		INNER JOIN universities u ON u.id = a.university_id
+		WHERE u.deleted_at IS NULL
+	) AS accreditations
+	WHERE (university_id = $1 OR $1 = 0)
+	AND (kind = $2 OR $2 = '')
+	AND (body = $3 OR $3 = '')
+	AND (level = $4 OR $4 = 0)
+	AND (to_tsvector('simple', scope) @@ plainto_tsquery('simple', $5) OR $5 = '')
+	AND (status = $6 OR $6 = '')
+	AND %s
+	ORDER BY %s
+	LIMIT $7 OFFSET $8`, strings.Join(sortKeys, ", "), accreditationStatus, keysetCond, orderBy)
+
+	args := []any{q.UniversityID, q.Kind, q.Body, q.Level, q.Scope, q.Status, filters.limit(), filters.offset()}
+	args = append(args, keysetArgs...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	accreditations := []*Accreditation{}
+	keys := []keysetKey{}
+	totalRecords := 0
+
+	for rows.Next() {
+		var (
+			accreditation Accreditation
+			sortValues    []string
+		)
+
+		err := rows.Scan(
+			&totalRecords,
+			&accreditation.ID,
+			&accreditation.CreatedAt,
+			&accreditation.UniversityID,
+			&accreditation.UniversityName,
+			&accreditation.ProgramID,
+			&accreditation.Kind,
+			&accreditation.Body,
+			&accreditation.Level,
+			&accreditation.Scope,
+			&accreditation.ValidFrom,
+			&accreditation.ValidTo,
+			&accreditation.Status,
+			&accreditation.Version,
+			pq.Array(&sortValues))
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		accreditations = append(accreditations, &accreditation)
+		keys = append(keys, keysetKey{ID: accreditation.ID, Values: sortValues})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	accreditations, metadata := keysetPage(filters, accreditations, keys, totalRecords)
+
+	return accreditations, metadata, nil
+}
+
+// nullInt64 stores the zero value of an optional id as NULL
+func nullInt64(i int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: i, Valid: i != 0}
+}
+
+// nullDay stores a missing date as NULL
+func nullDay(d *Day) any {
+	if d == nil {
+		return nil
+	}
+
+	return *d
+}
@@ -0,0 +1,170 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+// AnonymousAPIKey represents a request that did not provide an API key
+var AnonymousAPIKey = &APIKey{}
+
+type APIKey struct {
+	ID        int64      `json:"id"`
+	Plaintext string     `json:"key,omitempty"`
+	Hash      []byte     `json:"-"`
+	Owner     string     `json:"owner"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k *APIKey) IsAnonymous() bool {
+	return k == AnonymousAPIKey
+}
+
+func generateAPIKey(owner string) (*APIKey, error) {
+	key := &APIKey{
+		Owner: owner,
+	}
+
+	// 32 random bytes encode to a 52 character base32 string
+	randomBytes := make([]byte, 32)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Plaintext = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+
+	// only the SHA-256 hash of the key is stored in the database
+	hash := sha256.Sum256([]byte(key.Plaintext))
+	key.Hash = hash[:]
+
+	return key, nil
+}
+
+func ValidateAPIKeyPlaintext(v *validator.Validator, plaintext string) {
+	v.Check(plaintext != "", "key", "must be provided")
+	v.Check(len(plaintext) == 52, "key", "must be 52 bytes long")
+}
+
+func ValidateAPIKey(v *validator.Validator, key *APIKey) {
+	v.Check(key.Owner != "", "owner", "must be provided")
+	v.Check(len(key.Owner) <= 500, "owner", "must not be more than 500 bytes long")
+}
+
+type APIKeyModel struct {
+	DB *sql.DB
+}
+
+// New generates a new API key for the owner granted the permission codes, and
+// stores its hash in the database. The key and its permissions are stored
+// together, so a code that doesn't exist leaves no key behind. The plaintext
+// key is only available on the returned value.
+func (m APIKeyModel) New(owner string, codes ...string) (*APIKey, error) {
+	key, err := generateAPIKey(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO api_keys (hash, owner)
+		VALUES ($1, $2)
+		RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, key.Hash, key.Owner).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = addForAPIKey(ctx, tx, key.ID, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// GetForKey returns the API key matching the plaintext key, as long as it has not been revoked
+func (m APIKeyModel) GetForKey(plaintext string) (*APIKey, error) {
+	hash := sha256.Sum256([]byte(plaintext))
+
+	query := `
+		SELECT id, hash, owner, created_at, revoked_at
+		FROM api_keys
+		WHERE hash = $1 AND revoked_at IS NULL`
+
+	var key APIKey
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, hash[:]).Scan(
+		&key.ID,
+		&key.Hash,
+		&key.Owner,
+		&key.CreatedAt,
+		&key.RevokedAt)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &key, nil
+}
+
+func (m APIKeyModel) Revoke(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
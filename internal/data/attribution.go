@@ -0,0 +1,134 @@
+package data
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+// imageLicense is how a license is identified, named and linked to in an
+// attribution
+type imageLicense struct {
+	spdx string
+	name string
+	url  string
+}
+
+// imageLicenses are the licenses images may be used under, in the order they
+// are listed in. All of them allow the resizing done for thumbnails.
+var imageLicenses = []imageLicense{
+	{"CC0-1.0", "CC0 1.0", "https://creativecommons.org/publicdomain/zero/1.0/"},
+	{"CC-BY-2.0", "CC BY 2.0", "https://creativecommons.org/licenses/by/2.0/"},
+	{"CC-BY-2.5", "CC BY 2.5", "https://creativecommons.org/licenses/by/2.5/"},
+	{"CC-BY-3.0", "CC BY 3.0", "https://creativecommons.org/licenses/by/3.0/"},
+	{"CC-BY-4.0", "CC BY 4.0", "https://creativecommons.org/licenses/by/4.0/"},
+	{"CC-BY-SA-2.0", "CC BY-SA 2.0", "https://creativecommons.org/licenses/by-sa/2.0/"},
+	{"CC-BY-SA-2.5", "CC BY-SA 2.5", "https://creativecommons.org/licenses/by-sa/2.5/"},
+	{"CC-BY-SA-3.0", "CC BY-SA 3.0", "https://creativecommons.org/licenses/by-sa/3.0/"},
+	{"CC-BY-SA-4.0", "CC BY-SA 4.0", "https://creativecommons.org/licenses/by-sa/4.0/"},
+}
+
+// ImageLicenses are the SPDX identifiers of the licenses images may be used
+// under
+var ImageLicenses = func() []string {
+	ids := make([]string, len(imageLicenses))
+	for i, license := range imageLicenses {
+		ids[i] = license.spdx
+	}
+
+	return ids
+}()
+
+// ImageAttribution credits the author of a university's image and records
+// the terms it is used under. It is stored as a single jsonb column.
+type ImageAttribution struct {
+	Author    string `json:"author"`
+	SourceURL string `json:"source_url"`
+	// License is an SPDX identifier from ImageLicenses
+	License   string `json:"license"`
+	Retrieved Day    `json:"retrieved"`
+}
+
+// String renders the attribution as a credit line, such as
+// "Juan dela Cruz, CC BY-SA 4.0 <https://creativecommons.org/licenses/by-sa/4.0/>, via https://commons.wikimedia.org/..., retrieved 2024-06-01".
+// A nil attribution renders as an empty string.
+func (a *ImageAttribution) String() string {
+	if a == nil {
+		return ""
+	}
+
+	license := imageLicense{name: a.License}
+
+	for _, l := range imageLicenses {
+		if l.spdx == a.License {
+			license = l
+			break
+		}
+	}
+
+	credit := a.Author + ", " + license.name
+	if license.url != "" {
+		credit += " <" + license.url + ">"
+	}
+
+	return fmt.Sprintf("%s, via %s, retrieved %s", credit, a.SourceURL, time.Time(a.Retrieved).Format(time.DateOnly))
+}
+
+// imageAttributionFields has the fields of ImageAttribution without its
+// methods, so that they can be encoded without recursing
+type imageAttributionFields ImageAttribution
+
+// MarshalJSON adds the rendered credit line to the fields, so that clients
+// don't have to put it together themselves
+func (a ImageAttribution) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		imageAttributionFields
+		Text string `json:"text"`
+	}{imageAttributionFields(a), a.String()})
+}
+
+// Scan reads a jsonb column
+func (a *ImageAttribution) Scan(src any) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into ImageAttribution", src)
+	}
+
+	return json.Unmarshal(b, (*imageAttributionFields)(a))
+}
+
+// Value stores the fields without the rendered credit line, which is
+// derived from them. The JSON is passed as a string, since pq would send a
+// byte slice as bytea.
+func (a ImageAttribution) Value() (driver.Value, error) {
+	b, err := json.Marshal(imageAttributionFields(a))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+func ValidateImageAttribution(v *validator.Validator, a *ImageAttribution) {
+	v.Check(a.Author != "", "img_attribution.author", "must be provided")
+	v.Check(len(a.Author) <= 150, "img_attribution.author", "must not be more than 150 bytes long")
+
+	v.Check(a.SourceURL != "", "img_attribution.source_url", "must be provided")
+	v.Check(len(a.SourceURL) <= 500, "img_attribution.source_url", "must not be more than 500 bytes long")
+
+	if a.SourceURL != "" {
+		u, err := url.Parse(a.SourceURL)
+		v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "img_attribution.source_url", "must be an http or https URL")
+	}
+
+	v.Check(validator.PermittedValue(a.License, ImageLicenses...), "img_attribution.license", "must be one of "+strings.Join(ImageLicenses, ", "))
+
+	retrieved := time.Time(a.Retrieved)
+	v.Check(!retrieved.IsZero(), "img_attribution.retrieved", "must be provided")
+	v.Check(!retrieved.After(time.Now()), "img_attribution.retrieved", "must not be in the future")
+}
@@ -0,0 +1,61 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestImageAttributionString(t *testing.T) {
+	retrieved := Day(time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name        string
+		attribution *ImageAttribution
+		want        string
+	}{
+		{
+			name: "known license",
+			attribution: &ImageAttribution{
+				Author:    "Juan dela Cruz",
+				SourceURL: "https://commons.wikimedia.org/wiki/File:UST_Main_Building.jpg",
+				License:   "CC-BY-SA-4.0",
+				Retrieved: retrieved,
+			},
+			want: "Juan dela Cruz, CC BY-SA 4.0 <https://creativecommons.org/licenses/by-sa/4.0/>, via https://commons.wikimedia.org/wiki/File:UST_Main_Building.jpg, retrieved 2024-06-01",
+		},
+		{
+			name: "public domain",
+			attribution: &ImageAttribution{
+				Author:    "Maria Santos",
+				SourceURL: "https://example.com/oblation.jpg",
+				License:   "CC0-1.0",
+				Retrieved: retrieved,
+			},
+			want: "Maria Santos, CC0 1.0 <https://creativecommons.org/publicdomain/zero/1.0/>, via https://example.com/oblation.jpg, retrieved 2024-06-01",
+		},
+		{
+			name: "unknown license",
+			attribution: &ImageAttribution{
+				Author:    "Maria Santos",
+				SourceURL: "https://example.com/oblation.jpg",
+				License:   "All rights reserved",
+				Retrieved: retrieved,
+			},
+			want: "Maria Santos, All rights reserved, via https://example.com/oblation.jpg, retrieved 2024-06-01",
+		},
+		{
+			name: "nil",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.attribution.String()
+
+			if got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	AuditActionInsert  = "insert"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+)
+
+// AuditInfo describes who made a change and in which request, so that it can
+// be recorded alongside the change itself
+type AuditInfo struct {
+	Actor     string
+	RequestID string
+}
+
+type AuditEvent struct {
+	ID           int64           `json:"id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Actor        string          `json:"actor"`
+	Action       string          `json:"action"`
+	UniversityID int64           `json:"university_id"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+	RequestID    string          `json:"request_id"`
+}
+
+// insertAuditEvent records a change to a university as part of the transaction
+// that makes the change. A nil before or after value is stored as NULL.
+func insertAuditEvent(ctx context.Context, tx *sql.Tx, action string, universityID int64, before, after *University, info AuditInfo) error {
+	query := `
+		INSERT INTO audit_events (actor, action, university_id, before, after, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	beforeJSON, err := marshalNullable(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := marshalNullable(after)
+	if err != nil {
+		return err
+	}
+
+	args := []any{info.Actor, action, universityID, beforeJSON, afterJSON, info.RequestID}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func marshalNullable(university *University) ([]byte, error) {
+	if university == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(university)
+}
+
+type AuditModel struct {
+	DB *sql.DB
+}
+
+// GetAll returns the audit events for a single university, or for every
+// university when universityID is zero
+func (m AuditModel) GetAll(universityID int64, filters Filters) ([]*AuditEvent, Metadata, error) {
+	query := fmt.Sprintf(`
+	SELECT count(*) OVER(), id, created_at, actor, action, university_id, before, after, request_id
+	FROM audit_events
+	WHERE (university_id = $1 OR $1 = 0)
+	ORDER BY %s
+	LIMIT $2 OFFSET $3`, filters.orderBy())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, universityID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	events := []*AuditEvent{}
+	totalRecords := 0
+
+	for rows.Next() {
+		var event AuditEvent
+		err := rows.Scan(
+			&totalRecords,
+			&event.ID,
+			&event.CreatedAt,
+			&event.Actor,
+			&event.Action,
+			&event.UniversityID,
+			(*[]byte)(&event.Before),
+			(*[]byte)(&event.After),
+			&event.RequestID)
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return events, metadata, nil
+}
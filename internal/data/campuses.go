@@ -0,0 +1,348 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateCampus = errors.New("duplicate campus")
+
+type CampusModel struct {
+	DB *sql.DB
+}
+
+type Campus struct {
+	ID           int64     `json:"id"`
+	CreatedAt    time.Time `json:"-"`
+	UniversityID int64     `json:"university_id"`
+	Name         string    `json:"name"`
+	Address      string    `json:"address,omitempty"`
+	City         string    `json:"city,omitempty"`
+	Latitude     *float64  `json:"latitude,omitempty"`
+	Longitude    *float64  `json:"longitude,omitempty"`
+	Founded      int32     `json:"founded,omitempty"`
+	Website      string    `json:"website,omitempty"`
+	Version      int32     `json:"version"`
+}
+
+func ValidateCampus(v *validator.Validator, campus *Campus) {
+	v.Check(campus.Name != "", "name", "must be provided")
+	v.Check(len(campus.Name) <= 150, "name", "must not be more than 150 bytes long")
+
+	v.Check(len(campus.Address) <= 500, "address", "must not be more than 500 bytes long")
+	v.Check(len(campus.City) <= 150, "city", "must not be more than 150 bytes long")
+
+	ValidateCoordinates(v, "latitude", "longitude", campus.Latitude, campus.Longitude)
+
+	if campus.Founded != 0 {
+		v.Check(campus.Founded >= 1589, "founded", "must be greater than or equal to 1589")
+		v.Check(int(campus.Founded) <= time.Now().Year(), "founded", "must be less than or equal to the current year")
+	}
+
+	v.Check(len(campus.Website) <= 100, "website", "must not be more than 100 bytes long")
+}
+
+// writeForUniversity runs a change to the campuses of a university in a
+// transaction that also moves the university on to a new version and records
+// it in the audit log and revisions, since the campus names are part of the
+// university. The university is locked first, so the change is ordered
+// against updates to the university itself.
+func (m CampusModel) writeForUniversity(universityID int64, info AuditInfo, write func(ctx context.Context, tx *sql.Tx) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	universities := UniversityModel{DB: m.DB}
+
+	before, err := universities.getForUpdate(ctx, tx, universityID, false)
+	if err != nil {
+		return err
+	}
+
+	err = write(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE universities
+		SET version = version + 1
+		WHERE id = $1`
+
+	_, err = tx.ExecContext(ctx, query, universityID)
+	if err != nil {
+		return err
+	}
+
+	after, err := universities.getForUpdate(ctx, tx, universityID, false)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditEvent(ctx, tx, AuditActionUpdate, universityID, before, after, info)
+	if err != nil {
+		return err
+	}
+
+	err = insertRevision(ctx, tx, universityID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Insert adds the campus to its university, returning ErrRecordNotFound when
+// the university has been deleted
+func (m CampusModel) Insert(campus *Campus, info AuditInfo) error {
+	query := `
+		INSERT INTO campuses (university_id, name, address, city, latitude, longitude, founded, website)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, version`
+
+	args := []any{
+		campus.UniversityID,
+		campus.Name,
+		campus.Address,
+		campus.City,
+		campus.Latitude,
+		campus.Longitude,
+		nullInt32(campus.Founded),
+		campus.Website}
+
+	return m.writeForUniversity(campus.UniversityID, info, func(ctx context.Context, tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, args...).Scan(&campus.ID, &campus.CreatedAt, &campus.Version)
+		if err != nil {
+			switch {
+			case err.Error() == `pq: duplicate key value violates unique constraint "campuses_university_name_key"`:
+				return ErrDuplicateCampus
+			default:
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Get returns the campus with the given id, as long as it belongs to the
+// given university and the university hasn't been deleted
+func (m CampusModel) Get(universityID, id int64) (*Campus, error) {
+	if universityID < 1 || id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT c.id, c.created_at, c.university_id, c.name, c.address, c.city, c.latitude, c.longitude, COALESCE(c.founded, 0), c.website, c.version
+		FROM campuses c
+		INNER JOIN universities u ON u.id = c.university_id
+		WHERE c.university_id = $1 AND c.id = $2 AND u.deleted_at IS NULL`
+
+	var campus Campus
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, universityID, id).Scan(
+		&campus.ID,
+		&campus.CreatedAt,
+		&campus.UniversityID,
+		&campus.Name,
+		&campus.Address,
+		&campus.City,
+		&campus.Latitude,
+		&campus.Longitude,
+		&campus.Founded,
+		&campus.Website,
+		&campus.Version)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &campus, nil
+}
+
+func (m CampusModel) Update(campus *Campus, info AuditInfo) error {
+	// version is used to implement optimistic concurrency control
+	query := `
+		UPDATE campuses
+		SET name = $1, address = $2, city = $3, latitude = $4, longitude = $5, founded = $6, website = $7, version = version + 1
+		WHERE id = $8 AND version = $9
+		RETURNING version`
+
+	args := []any{
+		campus.Name,
+		campus.Address,
+		campus.City,
+		campus.Latitude,
+		campus.Longitude,
+		nullInt32(campus.Founded),
+		campus.Website,
+		campus.ID,
+		campus.Version}
+
+	err := m.writeForUniversity(campus.UniversityID, info, func(ctx context.Context, tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, args...).Scan(&campus.Version)
+		if err != nil {
+			switch {
+			case err.Error() == `pq: duplicate key value violates unique constraint "campuses_university_name_key"`:
+				return ErrDuplicateCampus
+			// sql.ErrNoRows in this case means that there was an edit conflict
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrEditConflict
+			default:
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	// the university was deleted since the client fetched the campus
+	if errors.Is(err, ErrRecordNotFound) {
+		return ErrEditConflict
+	}
+
+	return err
+}
+
+func (m CampusModel) Delete(universityID, id int64, info AuditInfo) error {
+	if universityID < 1 || id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM campuses
+		WHERE university_id = $1 AND id = $2`
+
+	return m.writeForUniversity(universityID, info, func(ctx context.Context, tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, universityID, id)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+
+		return nil
+	})
+}
+
+// GetAllForUniversity returns the campuses of a university in the order
+// they were added
+func (m CampusModel) GetAllForUniversity(universityID int64) ([]*Campus, error) {
+	query := `
+		SELECT id, created_at, university_id, name, address, city, latitude, longitude, COALESCE(founded, 0), website, version
+		FROM campuses
+		WHERE university_id = $1
+		ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, universityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	campuses := []*Campus{}
+
+	for rows.Next() {
+		var campus Campus
+
+		err := rows.Scan(
+			&campus.ID,
+			&campus.CreatedAt,
+			&campus.UniversityID,
+			&campus.Name,
+			&campus.Address,
+			&campus.City,
+			&campus.Latitude,
+			&campus.Longitude,
+			&campus.Founded,
+			&campus.Website,
+			&campus.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		campuses = append(campuses, &campus)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return campuses, nil
+}
+
+// syncCampusNames makes the university's campuses match the list of names
+// sent with the university itself. Campuses that are still listed keep
+// their details, unlisted ones are removed and new names are added in order.
+func syncCampusNames(ctx context.Context, tx *sql.Tx, universityID int64, names []string) error {
+	// a nil slice would be sent as NULL, which matches nothing below
+	if names == nil {
+		names = []string{}
+	}
+
+	// the campuses are locked so that they can't be renamed or removed while
+	// they are synced. New campuses are already held off by the lock on the
+	// university row, which their foreign key has to share.
+	query := `
+		SELECT id FROM campuses
+		WHERE university_id = $1
+		FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, universityID)
+	if err != nil {
+		return err
+	}
+
+	err = rows.Close()
+	if err != nil {
+		return err
+	}
+
+	query = `
+		DELETE FROM campuses
+		WHERE university_id = $1 AND NOT (name = ANY($2))`
+
+	_, err = tx.ExecContext(ctx, query, universityID, pq.Array(names))
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO campuses (university_id, name)
+		SELECT $1, name
+		FROM unnest($2::text[]) WITH ORDINALITY AS listed(name, position)
+		ORDER BY position
+		ON CONFLICT (university_id, name) DO NOTHING`
+
+	_, err = tx.ExecContext(ctx, query, universityID, pq.Array(names))
+	return err
+}
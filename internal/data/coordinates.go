@@ -0,0 +1,47 @@
+package data
+
+import (
+	"fmt"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+// The bounding box of the Philippines, from Balabac to the eastern tip of
+// Mindanao and from Tawi-Tawi to the Batanes islands. Every university and
+// campus in the dataset is expected to lie within it.
+const (
+	MinLatitude  = 4.5
+	MaxLatitude  = 21.5
+	MinLongitude = 116.0
+	MaxLongitude = 127.0
+)
+
+// MaxRadiusKm is the largest radius allowed in a near search, which is
+// enough to cover the whole country from anywhere in it
+const MaxRadiusKm = 2000
+
+// Coordinates is a point given as decimal degrees
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// ValidateCoordinates checks optional coordinates, which must be given as
+// a pair and lie within the Philippines. The errors are added under the given
+// keys, and name the coordinate they are about when both share a key.
+func ValidateCoordinates(v *validator.Validator, latitudeKey, longitudeKey string, latitude, longitude *float64) {
+	var latitudePrefix, longitudePrefix string
+	if latitudeKey == longitudeKey {
+		latitudePrefix, longitudePrefix = "latitude ", "longitude "
+	}
+
+	v.Check((latitude == nil) == (longitude == nil), latitudeKey, latitudePrefix+"must be provided together with longitude")
+
+	if latitude != nil {
+		v.Check(*latitude >= MinLatitude && *latitude <= MaxLatitude, latitudeKey, fmt.Sprintf("%smust be between %g and %g", latitudePrefix, MinLatitude, MaxLatitude))
+	}
+
+	if longitude != nil {
+		v.Check(*longitude >= MinLongitude && *longitude <= MaxLongitude, longitudeKey, fmt.Sprintf("%smust be between %g and %g", longitudePrefix, MinLongitude, MaxLongitude))
+	}
+}
@@ -0,0 +1,54 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+func TestValidateCoordinates(t *testing.T) {
+	ptr := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name       string
+		key        string
+		latitude   *float64
+		longitude  *float64
+		wantErrors []string
+	}{
+		{"none", "", nil, nil, nil},
+		{"Manila", "", ptr(14.5995), ptr(120.9842), nil},
+		{"corners of the bounding box", "", ptr(MinLatitude), ptr(MaxLongitude), nil},
+		{"latitude only", "", ptr(14.5995), nil, []string{"latitude"}},
+		{"longitude only", "", nil, ptr(120.9842), []string{"latitude"}},
+		{"latitude too far south", "", ptr(4.4), ptr(120.9842), []string{"latitude"}},
+		{"latitude too far north", "", ptr(21.6), ptr(120.9842), []string{"latitude"}},
+		{"longitude too far west", "", ptr(14.5995), ptr(115.9), []string{"longitude"}},
+		{"longitude too far east", "", ptr(14.5995), ptr(127.1), []string{"longitude"}},
+		{"swapped", "", ptr(120.9842), ptr(14.5995), []string{"latitude", "longitude"}},
+		{"near Manila", "near", ptr(14.5995), ptr(120.9842), nil},
+		{"near, swapped", "near", ptr(120.9842), ptr(14.5995), []string{"near"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			latitudeKey, longitudeKey := "latitude", "longitude"
+			if tt.key != "" {
+				latitudeKey, longitudeKey = tt.key, tt.key
+			}
+
+			ValidateCoordinates(v, latitudeKey, longitudeKey, tt.latitude, tt.longitude)
+
+			if len(v.Errors) != len(tt.wantErrors) {
+				t.Fatalf("errors = %v, want errors on %v", v.Errors, tt.wantErrors)
+			}
+
+			for _, key := range tt.wantErrors {
+				if _, ok := v.Errors[key]; !ok {
+					t.Errorf("errors = %v, want an error on %s", v.Errors, key)
+				}
+			}
+		})
+	}
+}
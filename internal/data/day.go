@@ -0,0 +1,48 @@
+package data
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Day is a calendar date without a time of day, encoded in JSON as
+// "2006-01-02"
+type Day time.Time
+
+func (d Day) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(time.Time(d).Format(time.DateOnly))), nil
+}
+
+func (d *Day) UnmarshalJSON(jsonValue []byte) error {
+	unquotedJSONValue, err := strconv.Unquote(string(jsonValue))
+	if err != nil {
+		return ErrInvalidDateFormat
+	}
+
+	t, err := time.Parse(time.DateOnly, unquotedJSONValue)
+	if err != nil {
+		return ErrInvalidDateFormat
+	}
+
+	*d = Day(t)
+
+	return nil
+}
+
+// Scan reads a date column
+func (d *Day) Scan(src any) error {
+	t, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Day", src)
+	}
+
+	*d = Day(t)
+
+	return nil
+}
+
+func (d Day) Value() (driver.Value, error) {
+	return time.Time(d), nil
+}
@@ -0,0 +1,417 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateEnrollmentStat = errors.New("duplicate enrollment stat")
+
+// EnrollmentSexes are the values enrollment is broken down by
+var EnrollmentSexes = []string{"female", "male"}
+
+// enrollmentGroups are what enrollment can be totalled by, along with the
+// expressions for the key and name of each group. Universities without a
+// region or type are put in a group of their own.
+var enrollmentGroups = map[string]struct{ key, name string }{
+	"region":           {key: "COALESCE(u.region_code, 'unassigned')", name: "COALESCE(max(r.name), '')"},
+	"institution_type": {key: "COALESCE(u.institution_type, 'unclassified')", name: "''"},
+}
+
+type EnrollmentModel struct {
+	DB *sql.DB
+}
+
+// EnrollmentStat is the number of students of one sex enrolled at one level
+// of a university in an academic year, and how many of them graduated
+type EnrollmentStat struct {
+	ID             int64        `json:"id"`
+	CreatedAt      time.Time    `json:"-"`
+	UniversityID   int64        `json:"university_id"`
+	UniversityName string       `json:"university_name,omitempty"`
+	AcademicYear   AcademicYear `json:"academic_year"`
+	Level          string       `json:"level"`
+	Sex            string       `json:"sex"`
+	Enrolled       int32        `json:"enrolled"`
+	Graduates      int32        `json:"graduates"`
+	Version        int32        `json:"version"`
+}
+
+func ValidateEnrollmentStat(v *validator.Validator, stat *EnrollmentStat) {
+	ValidateAcademicYear(v, "academic_year", stat.AcademicYear)
+
+	v.Check(validator.PermittedValue(stat.Level, ProgramLevels...), "level", "must be one of "+strings.Join(ProgramLevels, ", "))
+	v.Check(validator.PermittedValue(stat.Sex, EnrollmentSexes...), "sex", "must be one of "+strings.Join(EnrollmentSexes, ", "))
+
+	v.Check(stat.Enrolled >= 0, "enrolled", "must not be negative")
+	v.Check(stat.Graduates >= 0, "graduates", "must not be negative")
+	v.Check(stat.Graduates <= stat.Enrolled, "graduates", "must not be more than enrolled")
+}
+
+// EnrollmentQuery holds the filters that narrow down the stats returned by
+// GetAll and counted by Aggregate. Zero values leave the corresponding filter
+// unapplied.
+type EnrollmentQuery struct {
+	UniversityID    int64
+	AcademicYear    AcademicYear
+	Level           string
+	Sex             string
+	RegionCode      string
+	InstitutionType string
+}
+
+func ValidateEnrollmentQuery(v *validator.Validator, q EnrollmentQuery) {
+	if q.Level != "" {
+		v.Check(validator.PermittedValue(q.Level, ProgramLevels...), "level", "must be one of "+strings.Join(ProgramLevels, ", "))
+	}
+
+	if q.Sex != "" {
+		v.Check(validator.PermittedValue(q.Sex, EnrollmentSexes...), "sex", "must be one of "+strings.Join(EnrollmentSexes, ", "))
+	}
+
+	ValidatePSGC(v, "region", q.RegionCode)
+
+	if q.InstitutionType != "" {
+		v.Check(validator.PermittedValue(q.InstitutionType, InstitutionTypes...), "institution_type", "must be one of "+strings.Join(InstitutionTypes, ", "))
+	}
+}
+
+func (m EnrollmentModel) Insert(stat *EnrollmentStat) error {
+	query := `
+		INSERT INTO enrollment_stats (university_id, academic_year, level, sex, enrolled, graduates)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, version`
+
+	args := []any{stat.UniversityID, stat.AcademicYear, stat.Level, stat.Sex, stat.Enrolled, stat.Graduates}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&stat.ID, &stat.CreatedAt, &stat.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "enrollment_stats_key"`:
+			return ErrDuplicateEnrollmentStat
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the stat with the given id, as long as it belongs to the given
+// university and the university hasn't been deleted
+func (m EnrollmentModel) Get(universityID, id int64) (*EnrollmentStat, error) {
+	if universityID < 1 || id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT e.id, e.created_at, e.university_id, u.name, e.academic_year, e.level, e.sex, e.enrolled, e.graduates, e.version
+		FROM enrollment_stats e
+		INNER JOIN universities u ON u.id = e.university_id
+		WHERE e.university_id = $1 AND e.id = $2 AND u.deleted_at IS NULL`
+
+	var stat EnrollmentStat
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, universityID, id).Scan(
+		&stat.ID,
+		&stat.CreatedAt,
+		&stat.UniversityID,
+		&stat.UniversityName,
+		&stat.AcademicYear,
+		&stat.Level,
+		&stat.Sex,
+		&stat.Enrolled,
+		&stat.Graduates,
+		&stat.Version)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &stat, nil
+}
+
+func (m EnrollmentModel) Update(stat *EnrollmentStat) error {
+	// version is used to implement optimistic concurrency control
+	query := `
+		UPDATE enrollment_stats
+		SET academic_year = $1, level = $2, sex = $3, enrolled = $4, graduates = $5, version = version + 1
+		WHERE id = $6 AND version = $7
+		RETURNING version`
+
+	args := []any{
+		stat.AcademicYear,
+		stat.Level,
+		stat.Sex,
+		stat.Enrolled,
+		stat.Graduates,
+		stat.ID,
+		stat.Version}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&stat.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "enrollment_stats_key"`:
+			return ErrDuplicateEnrollmentStat
+		// sql.ErrNoRows in this case means that there was an edit conflict
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m EnrollmentModel) Delete(universityID, id int64) error {
+	if universityID < 1 || id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM enrollment_stats
+		WHERE university_id = $1 AND id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, universityID, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAll returns the stats matching the query across every university that
+// hasn't been deleted
+func (m EnrollmentModel) GetAll(q EnrollmentQuery, filters Filters) ([]*EnrollmentStat, Metadata, error) {
+	sortFields := filters.sortFields()
+	sortKeys := make([]string, len(sortFields))
+
+	for i, field := range sortFields {
+		sortKeys[i] = fmt.Sprintf("(%s)::text", field.column)
+	}
+
+	keysetCond, orderBy, keysetArgs := filters.keyset(sortFields, 9)
+
+	// the join happens in a subquery so that the filters and keyset clauses
+	// can refer to the columns without qualifying them
+	query := fmt.Sprintf(`
+	SELECT count(*) OVER(), id, created_at, university_id, university_name, academic_year, level, sex, enrolled, graduates, version, ARRAY[%s]
+	FROM (
+		SELECT e.*, u.name AS university_name, u.region_code, u.institution_type
+		FROM enrollment_stats e
+		INNER JOIN universities u ON u.id = e.university_id
+		WHERE u.deleted_at IS NULL
+	) AS enrollment_stats
+	WHERE (university_id = $1 OR $1 = 0)
+	AND (academic_year = $2 OR $2 = 0)
+	AND (level = $3 OR $3 = '')
+	AND (sex = $4 OR $4 = '')
+	AND (region_code = $5 OR $5 = '')
+	AND (institution_type = $6 OR $6 = '')
+	AND %s
+	ORDER BY %s
+	LIMIT $7 OFFSET $8`, strings.Join(sortKeys, ", "), keysetCond, orderBy)
+
+	args := []any{q.UniversityID, q.AcademicYear, q.Level, q.Sex, q.RegionCode, q.InstitutionType, filters.limit(), filters.offset()}
+	args = append(args, keysetArgs...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	stats := []*EnrollmentStat{}
+	keys := []keysetKey{}
+	totalRecords := 0
+
+	for rows.Next() {
+		var (
+			stat       EnrollmentStat
+			sortValues []string
+		)
+
+		err := rows.Scan(
+			&totalRecords,
+			&stat.ID,
+			&stat.CreatedAt,
+			&stat.UniversityID,
+			&stat.UniversityName,
+			&stat.AcademicYear,
+			&stat.Level,
+			&stat.Sex,
+			&stat.Enrolled,
+			&stat.Graduates,
+			&stat.Version,
+			pq.Array(&sortValues))
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		stats = append(stats, &stat)
+		keys = append(keys, keysetKey{ID: stat.ID, Values: sortValues})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	stats, metadata := keysetPage(filters, stats, keys, totalRecords)
+
+	return stats, metadata, nil
+}
+
+// EnrollmentTotal is the number of students enrolled and graduating in an
+// academic year, with the change from the year before it when that year is
+// on record. The change is counted over the universities reporting in both
+// years, so that a university reporting for the first time isn't mistaken
+// for growth.
+type EnrollmentTotal struct {
+	AcademicYear           AcademicYear `json:"academic_year"`
+	Enrolled               int64        `json:"enrolled"`
+	Graduates              int64        `json:"graduates"`
+	EnrolledChangePercent  *float64     `json:"enrolled_change_percent,omitempty"`
+	GraduatesChangePercent *float64     `json:"graduates_change_percent,omitempty"`
+}
+
+// EnrollmentAggregate holds the yearly totals of a region or an institution
+// type, oldest year first
+type EnrollmentAggregate struct {
+	Group  string             `json:"group"`
+	Name   string             `json:"name,omitempty"`
+	Totals []*EnrollmentTotal `json:"totals"`
+}
+
+// Aggregate totals the stats matching the query by region or by institution
+// type, and by academic year. Regions are named after the regions table.
+func (m EnrollmentModel) Aggregate(groupBy string, q EnrollmentQuery) ([]*EnrollmentAggregate, error) {
+	group, ok := enrollmentGroups[groupBy]
+	if !ok {
+		panic("unknown enrollment group: " + groupBy)
+	}
+
+	// the stats are totalled by university first, so that each year can be
+	// matched with the universities that reported the year before. The year
+	// before a filtered academic year is fetched for the change, but isn't
+	// returned itself.
+	query := fmt.Sprintf(`
+		WITH stats AS (
+			SELECT %s AS grp, %s AS name, e.university_id, e.academic_year, sum(e.enrolled) AS enrolled, sum(e.graduates) AS graduates
+			FROM enrollment_stats e
+			INNER JOIN universities u ON u.id = e.university_id
+			LEFT JOIN regions r ON r.code = u.region_code
+			WHERE u.deleted_at IS NULL
+			AND (e.university_id = $1 OR $1 = 0)
+			AND (e.academic_year = $2 OR e.academic_year = $2 - 1 OR $2 = 0)
+			AND (e.level = $3 OR $3 = '')
+			AND (e.sex = $4 OR $4 = '')
+			AND (u.region_code = $5 OR $5 = '')
+			AND (u.institution_type = $6 OR $6 = '')
+			GROUP BY grp, e.university_id, e.academic_year
+		)
+		SELECT s.grp, max(s.name), s.academic_year, sum(s.enrolled), sum(s.graduates),
+			COALESCE(sum(p.enrolled), 0), COALESCE(sum(s.enrolled) FILTER (WHERE p.university_id IS NOT NULL), 0),
+			COALESCE(sum(p.graduates), 0), COALESCE(sum(s.graduates) FILTER (WHERE p.university_id IS NOT NULL), 0)
+		FROM stats s
+		LEFT JOIN stats p ON p.university_id = s.university_id AND p.academic_year = s.academic_year - 1
+		WHERE s.academic_year = $2 OR $2 = 0
+		GROUP BY s.grp, s.academic_year
+		ORDER BY s.grp, s.academic_year`, group.key, group.name)
+
+	args := []any{q.UniversityID, q.AcademicYear, q.Level, q.Sex, q.RegionCode, q.InstitutionType}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aggregates := []*EnrollmentAggregate{}
+
+	var current *EnrollmentAggregate
+
+	for rows.Next() {
+		var (
+			aggregate EnrollmentAggregate
+			total     EnrollmentTotal
+			// the totals of the year before and of this year, counting
+			// only the universities that reported in both
+			prevEnrolled, matchedEnrolled   int64
+			prevGraduates, matchedGraduates int64
+		)
+
+		err := rows.Scan(
+			&aggregate.Group,
+			&aggregate.Name,
+			&total.AcademicYear,
+			&total.Enrolled,
+			&total.Graduates,
+			&prevEnrolled,
+			&matchedEnrolled,
+			&prevGraduates,
+			&matchedGraduates)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// the rows of a group are next to each other
+		if current == nil || current.Group != aggregate.Group {
+			current = &aggregate
+			current.Totals = []*EnrollmentTotal{}
+			aggregates = append(aggregates, current)
+		}
+
+		total.EnrolledChangePercent = percentChange(total.AcademicYear-1, prevEnrolled, total.AcademicYear, matchedEnrolled)
+		total.GraduatesChangePercent = percentChange(total.AcademicYear-1, prevGraduates, total.AcademicYear, matchedGraduates)
+
+		current.Totals = append(current.Totals, &total)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return aggregates, nil
+}
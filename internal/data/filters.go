@@ -1,8 +1,15 @@
 package data
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"math"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/liamgluna/kolehiyo/internal/validator"
 )
@@ -12,6 +19,14 @@ type Filters struct {
 	PageSize     int
 	Sort         string
 	SortSafelist []string
+	// Cursor switches from page numbers to keyset pagination, continuing
+	// from the position it encodes
+	Cursor string
+	// SortTypes maps the sort columns that aren't text to their Postgres
+	// type, one of "integer", "bigint", "double precision" or "date", so that
+	// the values in a cursor can be checked before they reach the database.
+	// id is always a bigint.
+	SortTypes map[string]string
 }
 
 func ValidateFilters(v *validator.Validator, f Filters) {
@@ -20,42 +35,306 @@ func ValidateFilters(v *validator.Validator, f Filters) {
 	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")
 	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")
 
-	v.Check(validator.PermittedValue(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
+	// each comma separated component is checked on its own so that the
+	// error can point at the one that is wrong
+	columns := make(map[string]bool)
+
+	for _, value := range strings.Split(f.Sort, ",") {
+		if !validator.PermittedValue(value, f.SortSafelist...) {
+			v.AddError("sort", fmt.Sprintf("invalid sort value %q", value))
+			break
+		}
+
+		column := strings.TrimPrefix(value, "-")
+		if columns[column] {
+			v.AddError("sort", fmt.Sprintf("duplicate sort value %q", value))
+			break
+		}
+
+		columns[column] = true
+	}
+
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		v.Check(err == nil, "cursor", "invalid cursor")
+		v.Check(err != nil || c.Sort == f.Sort, "cursor", "does not match the sort value")
+		v.Check(err != nil || len(c.Values) == len(strings.Split(f.Sort, ",")), "cursor", "invalid cursor")
+
+		// a value that doesn't fit its column would fail to cast in the
+		// query instead
+		if err == nil && c.Sort == f.Sort && len(c.Values) == len(strings.Split(f.Sort, ",")) {
+			for i, value := range strings.Split(f.Sort, ",") {
+				column := strings.TrimPrefix(value, "-")
+				if !validSortValue(f.sortType(column), c.Values[i]) {
+					v.AddError("cursor", "invalid cursor")
+					break
+				}
+			}
+		}
+	}
+}
+
+// sortType returns the Postgres type of a sort column, which is text unless
+// SortTypes says otherwise
+func (f Filters) sortType(column string) string {
+	if column == "id" {
+		return "bigint"
+	}
+
+	if t, ok := f.SortTypes[column]; ok {
+		return t
+	}
+
+	return "text"
+}
+
+// floatRX matches the numbers Postgres accepts for a double precision, which
+// unlike strconv.ParseFloat doesn't take hexadecimal or underscores
+var floatRX = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// validSortValue reports whether a cursor value can be cast to the type of
+// its sort column
+func validSortValue(typ, value string) bool {
+	switch typ {
+	case "integer":
+		_, err := strconv.ParseInt(value, 10, 32)
+		return err == nil
+	case "bigint":
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case "double precision":
+		return floatRX.MatchString(value)
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	default:
+		// Postgres text can't hold NUL characters
+		return !strings.ContainsRune(value, 0)
+	}
+}
+
+type sortField struct {
+	column    string
+	direction string
 }
 
-// Validate and extract column name from Sort field, removing any hyphen prefix.
-func (f Filters) sortColumn() string {
-	for _, safeValue := range f.SortSafelist {
-		if f.Sort == safeValue {
-			return strings.TrimPrefix(f.Sort, "-")
+// sortFields splits the Sort value into the columns to sort on and their
+// directions ("ASC", or "DESC" for a hyphen prefix). Every component must be
+// in the safelist.
+func (f Filters) sortFields() []sortField {
+	var fields []sortField
+
+	for _, value := range strings.Split(f.Sort, ",") {
+		if !validator.PermittedValue(value, f.SortSafelist...) {
+			panic("unsafe sort parameter: " + value)
+		}
+
+		direction := "ASC"
+		if strings.HasPrefix(value, "-") {
+			direction = "DESC"
 		}
+
+		fields = append(fields, sortField{column: strings.TrimPrefix(value, "-"), direction: direction})
 	}
 
-	panic("unsafe sort parameter: " + f.Sort)
+	return fields
 }
 
-// Return the sort direction ("ASC" or "DESC") based on the prefix of the Sort field.
-func (f Filters) sortDirection() string {
-	if strings.HasPrefix(f.Sort, "-") {
-		return "DESC"
+// orderBy returns the ORDER BY clause for the Sort value, with ties broken on id
+func (f Filters) orderBy() string {
+	return orderByClause(f.sortFields(), "ASC")
+}
+
+func orderByClause(fields []sortField, idDirection string) string {
+	var clause strings.Builder
+
+	for _, field := range fields {
+		fmt.Fprintf(&clause, "%s %s, ", field.column, field.direction)
 	}
-	return "ASC"
+
+	clause.WriteString("id " + idDirection)
+
+	return clause.String()
 }
 
+// limit fetches one extra row in cursor mode, which tells us whether there is
+// another page after this one
 func (f Filters) limit() int {
+	if f.Cursor != "" {
+		return f.PageSize + 1
+	}
+
 	return f.PageSize
 }
 
 func (f Filters) offset() int {
+	if f.Cursor != "" {
+		return 0
+	}
+
 	return (f.Page - 1) * f.PageSize
 }
 
+// cursor marks a row in a sorted listing by its sort key and id. Prev cursors
+// point at the page before the row rather than the one after it.
+type cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+	ID     int64    `json:"id"`
+	Prev   bool     `json:"p,omitempty"`
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+
+	js, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+
+	err = json.Unmarshal(js, &c)
+	return c, err
+}
+
+// keysetKey is the sort key and id of a row, as needed to build a cursor
+type keysetKey struct {
+	ID     int64
+	Values []string
+}
+
+func (f Filters) encodeCursor(key keysetKey, prev bool) string {
+	// marshaling a struct of strings, ints and bools cannot fail
+	js, _ := json.Marshal(cursor{Sort: f.Sort, Values: key.Values, ID: key.ID, Prev: prev})
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+// keyset returns the WHERE condition selecting the rows past the cursor and
+// the ORDER BY clause to walk them in. fields holds the SQL expressions being
+// sorted on, with ties broken on id. The cursor's sort key values and id are
+// passed as the parameters starting at $argPos, and are returned as args.
+// Without a cursor the condition matches every row.
+func (f Filters) keyset(fields []sortField, argPos int) (cond, orderBy string, args []any) {
+	if f.Cursor == "" {
+		return "TRUE", orderByClause(fields, "ASC"), nil
+	}
+
+	// the cursor was checked by ValidateFilters
+	c, _ := decodeCursor(f.Cursor)
+
+	idOp, idDirection := ">", "ASC"
+
+	// a previous page is found by walking backwards from the cursor, so
+	// every direction is flipped
+	if c.Prev {
+		idOp, idDirection = "<", "DESC"
+
+		reversed := make([]sortField, len(fields))
+		for i, field := range fields {
+			reversed[i] = field
+			if field.direction == "ASC" {
+				reversed[i].direction = "DESC"
+			} else {
+				reversed[i].direction = "ASC"
+			}
+		}
+
+		fields = reversed
+	}
+
+	// a row comes after the cursor when, for some column, all of the columns
+	// before it are equal and it is past the cursor's value in that column
+	var (
+		branches []string
+		equal    []string
+	)
+
+	for i, field := range fields {
+		op := ">"
+		if field.direction == "DESC" {
+			op = "<"
+		}
+
+		param := fmt.Sprintf("$%d", argPos+i)
+
+		branches = append(branches, "("+strings.Join(append(slices.Clone(equal), field.column+" "+op+" "+param), " AND ")+")")
+		equal = append(equal, field.column+" = "+param)
+		args = append(args, c.Values[i])
+	}
+
+	param := fmt.Sprintf("$%d", argPos+len(fields))
+	branches = append(branches, "("+strings.Join(append(equal, "id "+idOp+" "+param), " AND ")+")")
+	args = append(args, c.ID)
+
+	return "(" + strings.Join(branches, " OR ") + ")", orderByClause(fields, idDirection), args
+}
+
+// keysetPage finishes off a page of rows fetched using the limit, offset and
+// keyset clauses, returning the rows in listing order along with the metadata
+// for the page. keys holds the keysetKey of each row.
+func keysetPage[T any](f Filters, rows []T, keys []keysetKey, totalRecords int) ([]T, Metadata) {
+	if f.Cursor == "" {
+		metadata := calculateMetadata(totalRecords, f.Page, f.PageSize)
+
+		// cursors let clients switch to keyset pagination from any page
+		if len(keys) > 0 {
+			if f.offset()+len(keys) < totalRecords {
+				metadata.NextCursor = f.encodeCursor(keys[len(keys)-1], false)
+			}
+			if f.Page > 1 {
+				metadata.PrevCursor = f.encodeCursor(keys[0], true)
+			}
+		}
+
+		return rows, metadata
+	}
+
+	c, _ := decodeCursor(f.Cursor)
+
+	more := len(rows) > f.PageSize
+	if more {
+		rows = rows[:f.PageSize]
+		keys = keys[:f.PageSize]
+	}
+
+	// rows before a previous page cursor were fetched in reverse order
+	if c.Prev {
+		slices.Reverse(rows)
+		slices.Reverse(keys)
+	}
+
+	metadata := Metadata{PageSize: f.PageSize}
+
+	if len(keys) == 0 {
+		return rows, metadata
+	}
+
+	// there is always a page back in the direction the cursor came from
+	if c.Prev {
+		metadata.NextCursor = f.encodeCursor(keys[len(keys)-1], false)
+		if more {
+			metadata.PrevCursor = f.encodeCursor(keys[0], true)
+		}
+	} else {
+		metadata.PrevCursor = f.encodeCursor(keys[0], true)
+		if more {
+			metadata.NextCursor = f.encodeCursor(keys[len(keys)-1], false)
+		}
+	}
+
+	return rows, metadata
+}
+
 type Metadata struct {
-	CurrentPage  int `json:"current_page,omitempty"`
-	PageSize     int `json:"page_size,omitempty"`
-	FirstPage    int `json:"first_page,omitempty"`
-	LastPage     int `json:"last_page,omitempty"`
-	TotalRecords int `json:"total_records,omitempty"`
+	CurrentPage  int      `json:"current_page,omitempty"`
+	PageSize     int      `json:"page_size,omitempty"`
+	FirstPage    int      `json:"first_page,omitempty"`
+	LastPage     int      `json:"last_page,omitempty"`
+	TotalRecords int      `json:"total_records,omitempty"`
+	NextCursor   string   `json:"next_cursor,omitempty"`
+	PrevCursor   string   `json:"prev_cursor,omitempty"`
+	Suggestions  []string `json:"suggestions,omitempty"`
+	// Facets counts the matching records by the values of a field
+	Facets map[string]map[string]int `json:"facets,omitempty"`
 }
 
 func calculateMetadata(totalRecords, page, pageSize int) Metadata {
@@ -0,0 +1,215 @@
+package data
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	f := Filters{Sort: "-founded,name"}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		want    cursor
+		wantErr bool
+	}{
+		{
+			name:   "next",
+			cursor: f.encodeCursor(keysetKey{ID: 7, Values: []string{"1611", "University of Santo Tomas"}}, false),
+			want:   cursor{Sort: "-founded,name", Values: []string{"1611", "University of Santo Tomas"}, ID: 7},
+		},
+		{
+			name:   "prev",
+			cursor: f.encodeCursor(keysetKey{ID: 7, Values: []string{"1611", "University of Santo Tomas"}}, true),
+			want:   cursor{Sort: "-founded,name", Values: []string{"1611", "University of Santo Tomas"}, ID: 7, Prev: true},
+		},
+		{
+			name:    "not base64",
+			cursor:  "not a cursor!",
+			wantErr: true,
+		},
+		{
+			name:    "not JSON",
+			cursor:  base64.RawURLEncoding.EncodeToString([]byte("not JSON")),
+			wantErr: true,
+		},
+		{
+			name:    "wrong id type",
+			cursor:  base64.RawURLEncoding.EncodeToString([]byte(`{"s":"id","v":["1"],"id":"1"}`)),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.cursor)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeCursor(%q) = %+v, want an error", tt.cursor, got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("decodeCursor(%q) returned error: %v", tt.cursor, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeCursor(%q) = %+v, want %+v", tt.cursor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	byName := []sortField{{column: "name", direction: "ASC"}}
+	byFoundedThenName := []sortField{{column: "founded", direction: "DESC"}, {column: "name", direction: "ASC"}}
+
+	tests := []struct {
+		name        string
+		sort        string
+		fields      []sortField
+		key         *keysetKey
+		prev        bool
+		argPos      int
+		wantCond    string
+		wantOrderBy string
+		wantArgs    []any
+	}{
+		{
+			name:        "no cursor",
+			sort:        "name",
+			fields:      byName,
+			argPos:      3,
+			wantCond:    "TRUE",
+			wantOrderBy: "name ASC, id ASC",
+		},
+		{
+			name:        "next page",
+			sort:        "name",
+			fields:      byName,
+			key:         &keysetKey{ID: 7, Values: []string{"Silliman University"}},
+			argPos:      3,
+			wantCond:    "((name > $3) OR (name = $3 AND id > $4))",
+			wantOrderBy: "name ASC, id ASC",
+			wantArgs:    []any{"Silliman University", int64(7)},
+		},
+		{
+			name:        "previous page",
+			sort:        "name",
+			fields:      byName,
+			key:         &keysetKey{ID: 7, Values: []string{"Silliman University"}},
+			prev:        true,
+			argPos:      3,
+			wantCond:    "((name < $3) OR (name = $3 AND id < $4))",
+			wantOrderBy: "name DESC, id DESC",
+			wantArgs:    []any{"Silliman University", int64(7)},
+		},
+		{
+			name:        "several columns",
+			sort:        "-founded,name",
+			fields:      byFoundedThenName,
+			key:         &keysetKey{ID: 2, Values: []string{"1611", "University of Santo Tomas"}},
+			argPos:      1,
+			wantCond:    "((founded < $1) OR (founded = $1 AND name > $2) OR (founded = $1 AND name = $2 AND id > $3))",
+			wantOrderBy: "founded DESC, name ASC, id ASC",
+			wantArgs:    []any{"1611", "University of Santo Tomas", int64(2)},
+		},
+		{
+			name:        "several columns, previous page",
+			sort:        "-founded,name",
+			fields:      byFoundedThenName,
+			key:         &keysetKey{ID: 2, Values: []string{"1611", "University of Santo Tomas"}},
+			prev:        true,
+			argPos:      1,
+			wantCond:    "((founded > $1) OR (founded = $1 AND name < $2) OR (founded = $1 AND name = $2 AND id < $3))",
+			wantOrderBy: "founded ASC, name DESC, id DESC",
+			wantArgs:    []any{"1611", "University of Santo Tomas", int64(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Filters{Sort: tt.sort}
+			if tt.key != nil {
+				f.Cursor = f.encodeCursor(*tt.key, tt.prev)
+			}
+
+			cond, orderBy, args := f.keyset(tt.fields, tt.argPos)
+
+			if cond != tt.wantCond {
+				t.Errorf("cond = %q, want %q", cond, tt.wantCond)
+			}
+
+			if orderBy != tt.wantOrderBy {
+				t.Errorf("orderBy = %q, want %q", orderBy, tt.wantOrderBy)
+			}
+
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestValidateFiltersCursor(t *testing.T) {
+	cursorFor := func(sort string, values ...string) string {
+		return Filters{Sort: sort}.encodeCursor(keysetKey{ID: 1, Values: values}, false)
+	}
+
+	sortTypes := map[string]string{"level": "integer", "amount": "bigint", "distance": "double precision", "valid_from": "date"}
+
+	tests := []struct {
+		name   string
+		sort   string
+		cursor string
+		valid  bool
+	}{
+		{"text", "name", cursorFor("name", "Silliman University"), true},
+		{"text with a NUL", "name", cursorFor("name", "Silliman\x00University"), false},
+		{"id", "id", cursorFor("id", "42"), true},
+		{"id not a number", "id", cursorFor("id", "42; DROP TABLE"), false},
+		{"integer", "level", cursorFor("level", "3"), true},
+		{"integer out of range", "level", cursorFor("level", "3000000000"), false},
+		{"bigint", "-amount", cursorFor("-amount", "3000000000"), true},
+		{"double precision", "distance", cursorFor("distance", "12.5"), true},
+		{"double precision with exponent", "distance", cursorFor("distance", "1.5e-05"), true},
+		{"double precision in hexadecimal", "distance", cursorFor("distance", "0x1p-2"), false},
+		{"date", "valid_from", cursorFor("valid_from", "2024-06-01"), true},
+		{"date not a date", "valid_from", cursorFor("valid_from", "June 1"), false},
+		{"several columns", "level,name", cursorFor("level,name", "2", "Silliman University"), true},
+		{"different sort", "name", cursorFor("id", "1"), false},
+		{"missing value", "level,name", cursorFor("level,name", "2"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Filters{
+				Page:         1,
+				PageSize:     20,
+				Sort:         tt.sort,
+				SortSafelist: []string{"id", "name", "level", "-amount", "distance", "valid_from"},
+				Cursor:       tt.cursor,
+				SortTypes:    sortTypes,
+			}
+
+			v := validator.New()
+			ValidateFilters(v, f)
+
+			if v.Valid() != tt.valid {
+				t.Errorf("valid = %t, want %t (errors: %v)", v.Valid(), tt.valid, v.Errors)
+			}
+
+			if !tt.valid {
+				if _, ok := v.Errors["cursor"]; !ok {
+					t.Errorf("errors = %v, want an error on cursor", v.Errors)
+				}
+			}
+		})
+	}
+}
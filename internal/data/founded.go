@@ -8,34 +8,90 @@ import (
 
 var ErrInvalidDateFormat = errors.New("invalid date format")
 
-type Date time.Time
+const (
+	DatePrecisionYear  = "year"
+	DatePrecisionMonth = "month"
+	DatePrecisionDay   = "day"
+)
+
+var DatePrecisions = []string{DatePrecisionYear, DatePrecisionMonth, DatePrecisionDay}
+
+// datePrecisionLayouts are the layouts a Date is written in at each precision
+var datePrecisionLayouts = map[string]string{
+	DatePrecisionYear:  "2006",
+	DatePrecisionMonth: "2006-01",
+	DatePrecisionDay:   "2006-01-02",
+}
+
+// Date is a date that may only be known to the year or month. Time holds the
+// first day of the period, so a date known to the year is stored as January 1.
+type Date struct {
+	Time      time.Time
+	Precision string
+}
+
+// ParseDate reads a date written as "2006", "2006-01" or "2006-01-02", taking
+// its precision from the layout
+func ParseDate(s string) (Date, error) {
+	for _, precision := range DatePrecisions {
+		layout := datePrecisionLayouts[precision]
+
+		// time.Parse accepts single digit months and days, which would make
+		// the precision ambiguous
+		if len(s) != len(layout) {
+			continue
+		}
+
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return Date{Time: t, Precision: precision}, nil
+		}
+	}
+
+	return Date{}, ErrInvalidDateFormat
+}
+
+// String writes the date at its precision
+func (d Date) String() string {
+	layout, ok := datePrecisionLayouts[d.Precision]
+	if !ok {
+		layout = datePrecisionLayouts[DatePrecisionDay]
+	}
+
+	return d.Time.Format(layout)
+}
+
+// End returns the first day after the period the date covers
+func (d Date) End() time.Time {
+	switch d.Precision {
+	case DatePrecisionYear:
+		return d.Time.AddDate(1, 0, 0)
+	case DatePrecisionMonth:
+		return d.Time.AddDate(0, 1, 0)
+	default:
+		return d.Time.AddDate(0, 0, 1)
+	}
+}
 
 // Implement json.Marshaler and json.Unmarshaler interfaces
 
 // Because MarshalJSON() needs to return a byte slice and not
 // modify the receiver, we can use a value receiver for this method.
 func (d Date) MarshalJSON() ([]byte, error) {
-	// format the time.Time value using the desired layout and
+	// format the date at the precision it was given in and
 	// wrap it in double quotes before returning it
-	return []byte(strconv.Quote(time.Time(d).Format("2006"))), nil
+	return []byte(strconv.Quote(d.String())), nil
 }
 
 // IMPORTANT: Because UnmarshalJSON() needs to modify the
 // receiver, we must use a pointer receiver for this to work correctly.
 func (d *Date) UnmarshalJSON(jsonValue []byte) error {
-	// try to unmarshal the data into a time.Time
 	unquotedJSONValue, err := strconv.Unquote(string(jsonValue))
 	if err != nil {
 		return ErrInvalidDateFormat
 	}
 
-	t, err := time.Parse("2006-01-02", unquotedJSONValue)
-	if err != nil {
-		return ErrInvalidDateFormat
-	}
-
-	// then assign the time.Time value to the Date
-	*d = Date(t)
+	*d, err = ParseDate(unquotedJSONValue)
 
-	return nil
+	return err
 }
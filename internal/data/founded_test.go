@@ -0,0 +1,48 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		input         string
+		wantTime      time.Time
+		wantPrecision string
+		wantErr       error
+	}{
+		{"1611", time.Date(1611, time.January, 1, 0, 0, 0, 0, time.UTC), DatePrecisionYear, nil},
+		{"1611-04", time.Date(1611, time.April, 1, 0, 0, 0, 0, time.UTC), DatePrecisionMonth, nil},
+		{"1611-04-28", time.Date(1611, time.April, 28, 0, 0, 0, 0, time.UTC), DatePrecisionDay, nil},
+		{"2024-02-29", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), DatePrecisionDay, nil},
+		{"", time.Time{}, "", ErrInvalidDateFormat},
+		{"161", time.Time{}, "", ErrInvalidDateFormat},
+		{"1611-4", time.Time{}, "", ErrInvalidDateFormat},
+		{"1611-04-8", time.Time{}, "", ErrInvalidDateFormat},
+		{"1611-13", time.Time{}, "", ErrInvalidDateFormat},
+		{"2023-02-29", time.Time{}, "", ErrInvalidDateFormat},
+		{"28/04/1611", time.Time{}, "", ErrInvalidDateFormat},
+		{"1611-04-28T00:00:00Z", time.Time{}, "", ErrInvalidDateFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDate(tt.input)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseDate(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+
+			if !got.Time.Equal(tt.wantTime) || got.Precision != tt.wantPrecision {
+				t.Errorf("ParseDate(%q) = %v (%s), want %v (%s)", tt.input, got.Time, got.Precision, tt.wantTime, tt.wantPrecision)
+			}
+
+			// a parsed date is written back the way it was given
+			if err == nil && got.String() != tt.input {
+				t.Errorf("ParseDate(%q).String() = %q", tt.input, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrUnknownParent  = errors.New("unknown parent")
+	ErrHierarchyCycle = errors.New("hierarchy cycle")
+)
+
+// checkParent makes sure that the parent of a university exists and that
+// the university isn't among the parent's ancestors, which would turn the
+// hierarchy into a cycle
+func checkParent(ctx context.Context, tx *sql.Tx, university *University) error {
+	if university.ParentID == 0 {
+		return nil
+	}
+
+	// parents are checked one at a time, since two universities each made
+	// the parent of the other could otherwise both pass the check. The lock
+	// is taken before the query below, so that it sees the parent set by
+	// whoever held the lock last.
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('university_hierarchy'))`)
+	if err != nil {
+		return err
+	}
+
+	// UNION stops the walk should a cycle have slipped in regardless
+	query := `
+		WITH RECURSIVE ancestors (ancestor_id) AS (
+			SELECT $1::bigint
+			UNION
+			SELECT u.parent_id FROM universities u INNER JOIN ancestors a ON u.id = a.ancestor_id WHERE u.parent_id IS NOT NULL
+		)
+		SELECT
+			EXISTS (SELECT 1 FROM universities WHERE id = $1 AND deleted_at IS NULL),
+			EXISTS (SELECT 1 FROM ancestors WHERE ancestor_id = $2)`
+
+	var exists, cycle bool
+
+	err = tx.QueryRowContext(ctx, query, university.ParentID, university.ID).Scan(&exists, &cycle)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !exists:
+		return ErrUnknownParent
+	case cycle:
+		return ErrHierarchyCycle
+	}
+
+	return nil
+}
+
+// GetChildren returns the universities whose parent is the given university,
+// ordered by name
+func (m UniversityModel) GetChildren(id int64) ([]*University, error) {
+	columns, _ := new(University).scanFields(nil)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM universities
+		WHERE parent_id = $1 AND deleted_at IS NULL
+		ORDER BY name, id`, columns)
+
+	return m.getHierarchy(query, id)
+}
+
+// GetAncestors returns the parent of the given university, then the parent's
+// parent and so on up to the top of the hierarchy. Ancestors in the trash
+// are left out, but the ones above them are still returned.
+func (m UniversityModel) GetAncestors(id int64) ([]*University, error) {
+	columns, _ := new(University).scanFields(nil)
+
+	query := fmt.Sprintf(`
+		WITH RECURSIVE ancestors (ancestor_id, depth) AS (
+			SELECT parent_id, 1 FROM universities WHERE id = $1
+			UNION
+			SELECT u.parent_id, a.depth + 1 FROM universities u INNER JOIN ancestors a ON u.id = a.ancestor_id
+			WHERE a.depth < 100
+		)
+		SELECT %s
+		FROM universities
+		INNER JOIN ancestors a ON a.ancestor_id = universities.id
+		WHERE deleted_at IS NULL
+		ORDER BY a.depth`, columns)
+
+	return m.getHierarchy(query, id)
+}
+
+// getHierarchy runs a query selecting every university field, with the id of
+// a university as its only parameter
+func (m UniversityModel) getHierarchy(query string, id int64) ([]*University, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	universities := []*University{}
+
+	for rows.Next() {
+		var university University
+
+		_, dest := university.scanFields(nil)
+
+		err := rows.Scan(dest...)
+		if err != nil {
+			return nil, err
+		}
+
+		universities = append(universities, &university)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return universities, nil
+}
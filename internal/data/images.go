@@ -0,0 +1,141 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	ImageSizeOriginal = "original"
+	ImageSizeSmall    = "small"
+	ImageSizeMedium   = "medium"
+)
+
+var ImageSizes = []string{ImageSizeOriginal, ImageSizeSmall, ImageSizeMedium}
+
+// ImageThumbnailWidths are the widths in pixels that the thumbnails of an
+// image are scaled down to. Images narrower than a thumbnail are kept at
+// their own width.
+var ImageThumbnailWidths = map[string]int{
+	ImageSizeSmall:  160,
+	ImageSizeMedium: 640,
+}
+
+var ImageContentTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+type ImageModel struct {
+	DB *sql.DB
+}
+
+// Image describes an image uploaded for a university. The files themselves
+// are kept in a blob store under the keys returned by Key.
+type Image struct {
+	ID           int64     `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UniversityID int64     `json:"university_id"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+}
+
+// Key returns the blob store key of the image at the given size
+func (i *Image) Key(size string) string {
+	return fmt.Sprintf("images/%d/%s", i.ID, size)
+}
+
+// SizeContentType returns the content type of the image at the given size.
+// Thumbnails of PNG images stay PNG so that transparency is kept, while the
+// rest are encoded as JPEG.
+func (i *Image) SizeContentType(size string) string {
+	if size == ImageSizeOriginal || i.ContentType == "image/png" {
+		return i.ContentType
+	}
+
+	return "image/jpeg"
+}
+
+func (m ImageModel) Insert(image *Image) error {
+	query := `
+		INSERT INTO images (university_id, content_type, size, width, height)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	args := []any{image.UniversityID, image.ContentType, image.Size, image.Width, image.Height}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&image.ID, &image.CreatedAt)
+}
+
+// Get returns the image with the given id, as long as its university hasn't
+// been deleted
+func (m ImageModel) Get(id int64) (*Image, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT i.id, i.created_at, i.university_id, i.content_type, i.size, i.width, i.height
+		FROM images i
+		INNER JOIN universities u ON u.id = i.university_id
+		WHERE i.id = $1 AND u.deleted_at IS NULL`
+
+	var image Image
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&image.ID,
+		&image.CreatedAt,
+		&image.UniversityID,
+		&image.ContentType,
+		&image.Size,
+		&image.Width,
+		&image.Height)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &image, nil
+}
+
+func (m ImageModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM images
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
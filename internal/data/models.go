@@ -11,11 +11,39 @@ var (
 )
 
 type Models struct {
-	Universities UniversityModel
+	Accreditations AccreditationModel
+	AlternateNames AlternateNameModel
+	APIKeys        APIKeyModel
+	Audit          AuditModel
+	Campuses       CampusModel
+	Enrollment     EnrollmentModel
+	Images         ImageModel
+	Permissions    PermissionModel
+	Places         PlaceModel
+	Programs       ProgramModel
+	Revisions      RevisionModel
+	Tokens         TokenModel
+	Tuition        TuitionModel
+	Universities   UniversityModel
+	Users          UserModel
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Universities: UniversityModel{DB: db},
+		Accreditations: AccreditationModel{DB: db},
+		AlternateNames: AlternateNameModel{DB: db},
+		APIKeys:        APIKeyModel{DB: db},
+		Audit:          AuditModel{DB: db},
+		Campuses:       CampusModel{DB: db},
+		Enrollment:     EnrollmentModel{DB: db},
+		Images:         ImageModel{DB: db},
+		Permissions:    PermissionModel{DB: db},
+		Places:         PlaceModel{DB: db},
+		Programs:       ProgramModel{DB: db},
+		Revisions:      RevisionModel{DB: db},
+		Tokens:         TokenModel{DB: db},
+		Tuition:        TuitionModel{DB: db},
+		Universities:   UniversityModel{DB: db},
+		Users:          UserModel{DB: db},
 	}
 }
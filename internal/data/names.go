@@ -0,0 +1,246 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateAlternateName = errors.New("duplicate alternate name")
+
+var AlternateNameKinds = []string{"acronym", "former_name", "translation"}
+
+type AlternateNameModel struct {
+	DB *sql.DB
+}
+
+// AlternateName is another name a university is known by, such as its
+// acronym or the name it had before it became a university
+type AlternateName struct {
+	ID           int64     `json:"id"`
+	CreatedAt    time.Time `json:"-"`
+	UniversityID int64     `json:"university_id"`
+	Name         string    `json:"name"`
+	Kind         string    `json:"kind"`
+	// ValidFrom and ValidTo are the years the name was in use, when known
+	ValidFrom int32 `json:"valid_from,omitempty"`
+	ValidTo   int32 `json:"valid_to,omitempty"`
+	Version   int32 `json:"version"`
+}
+
+func ValidateAlternateName(v *validator.Validator, name *AlternateName) {
+	v.Check(name.Name != "", "name", "must be provided")
+	v.Check(len(name.Name) <= 150, "name", "must not be more than 150 bytes long")
+
+	v.Check(validator.PermittedValue(name.Kind, AlternateNameKinds...), "kind", "must be one of "+strings.Join(AlternateNameKinds, ", "))
+
+	if name.ValidFrom != 0 {
+		v.Check(name.ValidFrom >= 1589, "valid_from", "must be greater than or equal to 1589")
+		v.Check(int(name.ValidFrom) <= time.Now().Year(), "valid_from", "must be less than or equal to the current year")
+	}
+
+	if name.ValidTo != 0 {
+		v.Check(name.ValidTo >= 1589, "valid_to", "must be greater than or equal to 1589")
+		v.Check(int(name.ValidTo) <= time.Now().Year(), "valid_to", "must be less than or equal to the current year")
+	}
+
+	if name.ValidFrom != 0 && name.ValidTo != 0 {
+		v.Check(name.ValidFrom <= name.ValidTo, "valid_from", "must be less than or equal to valid_to")
+	}
+}
+
+func (m AlternateNameModel) Insert(name *AlternateName) error {
+	query := `
+		INSERT INTO university_names (university_id, name, kind, valid_from, valid_to)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, version`
+
+	args := []any{name.UniversityID, name.Name, name.Kind, nullInt32(name.ValidFrom), nullInt32(name.ValidTo)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&name.ID, &name.CreatedAt, &name.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "university_names_university_name_key"`:
+			return ErrDuplicateAlternateName
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the alternate name with the given id, as long as it belongs to
+// the given university and the university hasn't been deleted
+func (m AlternateNameModel) Get(universityID, id int64) (*AlternateName, error) {
+	if universityID < 1 || id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT n.id, n.created_at, n.university_id, n.name, n.kind, COALESCE(n.valid_from, 0), COALESCE(n.valid_to, 0), n.version
+		FROM university_names n
+		INNER JOIN universities u ON u.id = n.university_id
+		WHERE n.university_id = $1 AND n.id = $2 AND u.deleted_at IS NULL`
+
+	var name AlternateName
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, universityID, id).Scan(
+		&name.ID,
+		&name.CreatedAt,
+		&name.UniversityID,
+		&name.Name,
+		&name.Kind,
+		&name.ValidFrom,
+		&name.ValidTo,
+		&name.Version)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &name, nil
+}
+
+func (m AlternateNameModel) Update(name *AlternateName) error {
+	// version is used to implement optimistic concurrency control
+	query := `
+		UPDATE university_names
+		SET name = $1, kind = $2, valid_from = $3, valid_to = $4, version = version + 1
+		WHERE id = $5 AND version = $6
+		RETURNING version`
+
+	args := []any{
+		name.Name,
+		name.Kind,
+		nullInt32(name.ValidFrom),
+		nullInt32(name.ValidTo),
+		name.ID,
+		name.Version}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&name.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "university_names_university_name_key"`:
+			return ErrDuplicateAlternateName
+		// sql.ErrNoRows in this case means that there was an edit conflict
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m AlternateNameModel) Delete(universityID, id int64) error {
+	if universityID < 1 || id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM university_names
+		WHERE university_id = $1 AND id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, universityID, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAllForUniversity returns the alternate names of a university, with
+// names that are still in use first and the rest from newest to oldest
+func (m AlternateNameModel) GetAllForUniversity(universityID int64) ([]*AlternateName, error) {
+	names, err := m.GetAllForUniversities([]int64{universityID})
+	if err != nil {
+		return nil, err
+	}
+
+	return names[universityID], nil
+}
+
+// GetAllForUniversities returns the alternate names of each university, keyed
+// by university id
+func (m AlternateNameModel) GetAllForUniversities(universityIDs []int64) (map[int64][]*AlternateName, error) {
+	query := `
+		SELECT id, created_at, university_id, name, kind, COALESCE(valid_from, 0), COALESCE(valid_to, 0), version
+		FROM university_names
+		WHERE university_id = ANY($1)
+		ORDER BY university_id, valid_to DESC NULLS FIRST, name, id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(universityIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// every university gets an entry, so that universities without
+	// alternate names are encoded as an empty list rather than null
+	names := make(map[int64][]*AlternateName, len(universityIDs))
+	for _, id := range universityIDs {
+		names[id] = []*AlternateName{}
+	}
+
+	for rows.Next() {
+		var name AlternateName
+
+		err := rows.Scan(
+			&name.ID,
+			&name.CreatedAt,
+			&name.UniversityID,
+			&name.Name,
+			&name.Kind,
+			&name.ValidFrom,
+			&name.ValidTo,
+			&name.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		names[name.UniversityID] = append(names[name.UniversityID], &name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
@@ -0,0 +1,185 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrUnknownPermission = errors.New("unknown permission")
+
+type Permissions []string
+
+// Include reports whether the permission code is in the slice
+func (p Permissions) Include(code string) bool {
+	return slices.Contains(p, code)
+}
+
+type PermissionModel struct {
+	DB *sql.DB
+}
+
+// GetAllForAPIKey returns all permission codes granted to the API key
+func (m PermissionModel) GetAllForAPIKey(apiKeyID int64) (Permissions, error) {
+	query := `
+		SELECT permissions.code
+		FROM permissions
+		INNER JOIN api_keys_permissions ON api_keys_permissions.permission_id = permissions.id
+		WHERE api_keys_permissions.api_key_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions Permissions
+
+	for rows.Next() {
+		var permission string
+
+		err := rows.Scan(&permission)
+		if err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// addForAPIKey grants the permission codes to the API key within the
+// transaction, returning ErrUnknownPermission for codes that don't exist
+func addForAPIKey(ctx context.Context, tx *sql.Tx, apiKeyID int64, codes []string) error {
+	err := checkPermissionCodes(ctx, tx, codes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO api_keys_permissions
+		SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)
+		ON CONFLICT DO NOTHING`
+
+	_, err = tx.ExecContext(ctx, query, apiKeyID, pq.Array(codes))
+	return err
+}
+
+// checkPermissionCodes returns ErrUnknownPermission, naming the codes, when
+// any of the codes doesn't exist
+func checkPermissionCodes(ctx context.Context, tx *sql.Tx, codes []string) error {
+	query := `
+		SELECT code FROM unnest($1::text[]) AS code
+		WHERE code NOT IN (SELECT code FROM permissions)`
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(codes))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var unknown []string
+
+	for rows.Next() {
+		var code string
+
+		err := rows.Scan(&code)
+		if err != nil {
+			return err
+		}
+
+		unknown = append(unknown, fmt.Sprintf("%q", code))
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w %s", ErrUnknownPermission, strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// GetAllForUser returns all permission codes granted to the user
+func (m PermissionModel) GetAllForUser(userID int64) (Permissions, error) {
+	query := `
+		SELECT permissions.code
+		FROM permissions
+		INNER JOIN users_permissions ON users_permissions.permission_id = permissions.id
+		WHERE users_permissions.user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions Permissions
+
+	for rows.Next() {
+		var permission string
+
+		err := rows.Scan(&permission)
+		if err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// AddForUser grants the permission codes to the user. Codes that the user
+// already has are ignored, while codes that don't exist are an error and
+// nothing is granted.
+func (m PermissionModel) AddForUser(userID int64, codes ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = checkPermissionCodes(ctx, tx, codes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO users_permissions
+		SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)
+		ON CONFLICT DO NOTHING`
+
+	_, err = tx.ExecContext(ctx, query, userID, pq.Array(codes))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,320 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+)
+
+// PSGCRX matches a 10-digit Philippine Standard Geographic Code
+var PSGCRX = regexp.MustCompile(`^[0-9]{10}$`)
+
+var (
+	ErrUnknownRegion    = errors.New("unknown region")
+	ErrUnknownProvince  = errors.New("unknown province")
+	ErrUnknownCity      = errors.New("unknown city")
+	ErrRegionMismatch   = errors.New("region does not contain the province or city")
+	ErrProvinceMismatch = errors.New("province does not contain the city")
+)
+
+type Region struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+type Province struct {
+	Code       string `json:"code"`
+	RegionCode string `json:"region_code"`
+	Name       string `json:"name"`
+}
+
+// City is a city or municipality. Cities in Metro Manila and highly
+// urbanized cities have no province.
+type City struct {
+	Code         string `json:"code"`
+	RegionCode   string `json:"region_code"`
+	ProvinceCode string `json:"province_code,omitempty"`
+	Name         string `json:"name"`
+	Kind         string `json:"kind"`
+}
+
+// ValidatePSGC checks an optional PSGC code
+func ValidatePSGC(v *validator.Validator, key, code string) {
+	if code != "" {
+		v.Check(validator.Matches(code, PSGCRX), key, "must be a 10-digit PSGC code")
+	}
+}
+
+type PlaceModel struct {
+	DB *sql.DB
+}
+
+func (m PlaceModel) GetAllRegions() ([]*Region, error) {
+	query := `
+		SELECT code, name
+		FROM regions
+		ORDER BY code`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	regions := []*Region{}
+
+	for rows.Next() {
+		var region Region
+
+		err := rows.Scan(&region.Code, &region.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		regions = append(regions, &region)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return regions, nil
+}
+
+func (m PlaceModel) GetRegion(code string) (*Region, error) {
+	query := `
+		SELECT code, name
+		FROM regions
+		WHERE code = $1`
+
+	var region Region
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, code).Scan(&region.Code, &region.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &region, nil
+}
+
+// GetAllProvinces returns the provinces of a region
+func (m PlaceModel) GetAllProvinces(regionCode string) ([]*Province, error) {
+	query := `
+		SELECT code, region_code, name
+		FROM provinces
+		WHERE region_code = $1
+		ORDER BY name`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, regionCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	provinces := []*Province{}
+
+	for rows.Next() {
+		var province Province
+
+		err := rows.Scan(&province.Code, &province.RegionCode, &province.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		provinces = append(provinces, &province)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return provinces, nil
+}
+
+func (m PlaceModel) GetProvince(code string) (*Province, error) {
+	query := `
+		SELECT code, region_code, name
+		FROM provinces
+		WHERE code = $1`
+
+	var province Province
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, code).Scan(&province.Code, &province.RegionCode, &province.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &province, nil
+}
+
+// GetAllCities returns the cities and municipalities of a region, or of a
+// province when provinceCode is given
+func (m PlaceModel) GetAllCities(regionCode, provinceCode string) ([]*City, error) {
+	query := `
+		SELECT code, region_code, COALESCE(province_code, ''), name, kind
+		FROM cities
+		WHERE (region_code = $1 OR $1 = '')
+		AND (province_code = $2 OR $2 = '')
+		ORDER BY name, code`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, regionCode, provinceCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cities := []*City{}
+
+	for rows.Next() {
+		var city City
+
+		err := rows.Scan(&city.Code, &city.RegionCode, &city.ProvinceCode, &city.Name, &city.Kind)
+		if err != nil {
+			return nil, err
+		}
+
+		cities = append(cities, &city)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cities, nil
+}
+
+// Import adds or renames the given regions, provinces and cities. Parents
+// are written before their children, so that the references hold.
+func (m PlaceModel) Import(regions []*Region, provinces []*Province, cities []*City) error {
+	// the full PSGC list has a few thousand entries
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, region := range regions {
+		query := `
+			INSERT INTO regions (code, name)
+			VALUES ($1, $2)
+			ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name`
+
+		_, err := tx.ExecContext(ctx, query, region.Code, region.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, province := range provinces {
+		query := `
+			INSERT INTO provinces (code, region_code, name)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (code) DO UPDATE SET region_code = EXCLUDED.region_code, name = EXCLUDED.name`
+
+		_, err := tx.ExecContext(ctx, query, province.Code, province.RegionCode, province.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, city := range cities {
+		query := `
+			INSERT INTO cities (code, region_code, province_code, name, kind)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+			ON CONFLICT (code) DO UPDATE
+			SET region_code = EXCLUDED.region_code, province_code = EXCLUDED.province_code, name = EXCLUDED.name, kind = EXCLUDED.kind`
+
+		_, err := tx.ExecContext(ctx, query, city.Code, city.RegionCode, city.ProvinceCode, city.Name, city.Kind)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// resolvePlace fills in the region and province of a university from the
+// most specific PSGC code it was given, so that the three always agree. The
+// broader codes that were given too must contain the more specific one.
+func resolvePlace(ctx context.Context, tx *sql.Tx, university *University) error {
+	var (
+		query        string
+		code         string
+		notFound     error
+		regionCode   string
+		provinceCode string
+	)
+
+	// only cities are looked up along with their province
+	dest := []any{&regionCode}
+
+	switch {
+	case university.CityCode != "":
+		query = `SELECT region_code, COALESCE(province_code, '') FROM cities WHERE code = $1`
+		code, notFound, dest = university.CityCode, ErrUnknownCity, append(dest, &provinceCode)
+	case university.ProvinceCode != "":
+		query = `SELECT region_code FROM provinces WHERE code = $1`
+		code, notFound = university.ProvinceCode, ErrUnknownProvince
+	case university.RegionCode != "":
+		query = `SELECT code FROM regions WHERE code = $1`
+		code, notFound = university.RegionCode, ErrUnknownRegion
+	default:
+		return nil
+	}
+
+	err := tx.QueryRowContext(ctx, query, code).Scan(dest...)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return notFound
+		default:
+			return err
+		}
+	}
+
+	if university.RegionCode != "" && university.RegionCode != regionCode {
+		return ErrRegionMismatch
+	}
+	university.RegionCode = regionCode
+
+	if university.CityCode != "" {
+		if university.ProvinceCode != "" && university.ProvinceCode != provinceCode {
+			return ErrProvinceMismatch
+		}
+		university.ProvinceCode = provinceCode
+	}
+
+	return nil
+}
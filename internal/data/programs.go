@@ -0,0 +1,349 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateProgram = errors.New("duplicate program")
+
+var (
+	ProgramLevels   = []string{"certificate", "associate", "bachelor", "master", "doctorate"}
+	ProgramStatuses = []string{"active", "suspended", "discontinued"}
+)
+
+type ProgramModel struct {
+	DB *sql.DB
+}
+
+type Program struct {
+	ID             int64     `json:"id"`
+	CreatedAt      time.Time `json:"-"`
+	UniversityID   int64     `json:"university_id"`
+	UniversityName string    `json:"university_name,omitempty"`
+	Name           string    `json:"name"`
+	Level          string    `json:"level"`
+	Discipline     string    `json:"discipline"`
+	DurationMonths int32     `json:"duration_months,omitempty"`
+	Status         string    `json:"status"`
+	Version        int32     `json:"version"`
+}
+
+func ValidateProgram(v *validator.Validator, program *Program) {
+	v.Check(program.Name != "", "name", "must be provided")
+	v.Check(len(program.Name) <= 300, "name", "must not be more than 300 bytes long")
+
+	v.Check(validator.PermittedValue(program.Level, ProgramLevels...), "level", "must be one of "+strings.Join(ProgramLevels, ", "))
+
+	v.Check(program.Discipline != "", "discipline", "must be provided")
+	v.Check(len(program.Discipline) <= 150, "discipline", "must not be more than 150 bytes long")
+
+	// the longest programs, such as medicine, run for about a decade
+	v.Check(program.DurationMonths >= 0, "duration_months", "must not be negative")
+	v.Check(program.DurationMonths <= 180, "duration_months", "must not be more than 180 months")
+
+	v.Check(validator.PermittedValue(program.Status, ProgramStatuses...), "status", "must be one of "+strings.Join(ProgramStatuses, ", "))
+}
+
+// ProgramQuery holds the filters that narrow down the programs returned by
+// GetAll. Zero values leave the corresponding filter unapplied.
+type ProgramQuery struct {
+	UniversityID int64
+	Name         string
+	Level        string
+	Discipline   string
+	Status       string
+}
+
+func ValidateProgramQuery(v *validator.Validator, q ProgramQuery) {
+	v.Check(len(q.Name) <= 300, "name", "must not be more than 300 bytes long")
+	v.Check(len(q.Discipline) <= 150, "discipline", "must not be more than 150 bytes long")
+
+	if q.Level != "" {
+		v.Check(validator.PermittedValue(q.Level, ProgramLevels...), "level", "must be one of "+strings.Join(ProgramLevels, ", "))
+	}
+
+	if q.Status != "" {
+		v.Check(validator.PermittedValue(q.Status, ProgramStatuses...), "status", "must be one of "+strings.Join(ProgramStatuses, ", "))
+	}
+}
+
+func (m ProgramModel) Insert(program *Program) error {
+	query := `
+		INSERT INTO programs (university_id, name, level, discipline, duration_months, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, version`
+
+	args := []any{program.UniversityID, program.Name, program.Level, program.Discipline, nullInt32(program.DurationMonths), program.Status}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&program.ID, &program.CreatedAt, &program.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "programs_university_name_level_key"`:
+			return ErrDuplicateProgram
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the program with the given id, as long as its university
+// hasn't been deleted
+func (m ProgramModel) Get(id int64) (*Program, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT p.id, p.created_at, p.university_id, u.name, p.name, p.level, p.discipline, COALESCE(p.duration_months, 0), p.status, p.version
+		FROM programs p
+		INNER JOIN universities u ON u.id = p.university_id
+		WHERE p.id = $1 AND u.deleted_at IS NULL`
+
+	var program Program
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&program.ID,
+		&program.CreatedAt,
+		&program.UniversityID,
+		&program.UniversityName,
+		&program.Name,
+		&program.Level,
+		&program.Discipline,
+		&program.DurationMonths,
+		&program.Status,
+		&program.Version)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &program, nil
+}
+
+func (m ProgramModel) Update(program *Program) error {
+	// version is used to implement optimistic concurrency control
+	query := `
+		UPDATE programs
+		SET name = $1, level = $2, discipline = $3, duration_months = $4, status = $5, version = version + 1
+		WHERE id = $6 AND version = $7
+		RETURNING version`
+
+	args := []any{
+		program.Name,
+		program.Level,
+		program.Discipline,
+		nullInt32(program.DurationMonths),
+		program.Status,
+		program.ID,
+		program.Version}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&program.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "programs_university_name_level_key"`:
+			return ErrDuplicateProgram
+		// sql.ErrNoRows in this case means that there was an edit conflict
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m ProgramModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM programs
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAll returns the programs matching the query across every university
+// that hasn't been deleted
+func (m ProgramModel) GetAll(q ProgramQuery, filters Filters) ([]*Program, Metadata, error) {
+	sortFields := filters.sortFields()
+	sortKeys := make([]string, len(sortFields))
+
+	for i, field := range sortFields {
+		sortKeys[i] = fmt.Sprintf("(%s)::text", field.column)
+	}
+
+	keysetCond, orderBy, keysetArgs := filters.keyset(sortFields, 8)
+
+	// the join happens in a subquery so that the filters and keyset
+	// clauses can refer to the program columns without qualifying them
+	query := fmt.Sprintf(`
+	SELECT count(*) OVER(), id, created_at, university_id, university_name, name, level, discipline, COALESCE(duration_months, 0), status, version, ARRAY[%s]
+	FROM (
+		SELECT p.*, u.name AS university_name
+		FROM programs p
+		INNER JOIN universities u ON u.id = p.university_id
+		WHERE u.deleted_at IS NULL
+	) AS programs
+	WHERE (university_id = $1 OR $1 = 0)
+	AND (to_tsvector('simple', name) @@ plainto_tsquery('simple', $2) OR $2 = '')
+	AND (level = $3 OR $3 = '')
+	AND (lower(discipline) = lower($4) OR $4 = '')
+	AND (status = $5 OR $5 = '')
+	AND %s
+	ORDER BY %s
+	LIMIT $6 OFFSET $7`, strings.Join(sortKeys, ", "), keysetCond, orderBy)
+
+	args := []any{q.UniversityID, q.Name, q.Level, q.Discipline, q.Status, filters.limit(), filters.offset()}
+	args = append(args, keysetArgs...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	programs := []*Program{}
+	keys := []keysetKey{}
+	totalRecords := 0
+
+	for rows.Next() {
+		var (
+			program    Program
+			sortValues []string
+		)
+
+		err := rows.Scan(
+			&totalRecords,
+			&program.ID,
+			&program.CreatedAt,
+			&program.UniversityID,
+			&program.UniversityName,
+			&program.Name,
+			&program.Level,
+			&program.Discipline,
+			&program.DurationMonths,
+			&program.Status,
+			&program.Version,
+			pq.Array(&sortValues))
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		programs = append(programs, &program)
+		keys = append(keys, keysetKey{ID: program.ID, Values: sortValues})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	programs, metadata := keysetPage(filters, programs, keys, totalRecords)
+
+	return programs, metadata, nil
+}
+
+// GetAllForUniversities returns the programs offered by each university,
+// keyed by university id
+func (m ProgramModel) GetAllForUniversities(universityIDs []int64) (map[int64][]*Program, error) {
+	query := `
+		SELECT id, created_at, university_id, name, level, discipline, COALESCE(duration_months, 0), status, version
+		FROM programs
+		WHERE university_id = ANY($1)
+		ORDER BY university_id, name, id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(universityIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// every university gets an entry, so that universities without
+	// programs are encoded as an empty list rather than null
+	programs := make(map[int64][]*Program, len(universityIDs))
+	for _, id := range universityIDs {
+		programs[id] = []*Program{}
+	}
+
+	for rows.Next() {
+		var program Program
+
+		err := rows.Scan(
+			&program.ID,
+			&program.CreatedAt,
+			&program.UniversityID,
+			&program.Name,
+			&program.Level,
+			&program.Discipline,
+			&program.DurationMonths,
+			&program.Status,
+			&program.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		programs[program.UniversityID] = append(programs[program.UniversityID], &program)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return programs, nil
+}
+
+// nullInt32 stores the zero value of an optional integer as NULL
+func nullInt32(i int32) sql.NullInt32 {
+	return sql.NullInt32{Int32: i, Valid: i != 0}
+}
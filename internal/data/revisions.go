@@ -0,0 +1,318 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Revision struct {
+	Version    int32       `json:"version"`
+	CreatedAt  time.Time   `json:"created_at"`
+	University *University `json:"university,omitempty"`
+}
+
+// FieldChange holds the values of a single field in two revisions
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// snapshotCampuses reads the campus names out of a snapshot. Universities
+// without campuses may have a null instead of an empty list.
+const snapshotCampuses = `ARRAY(SELECT jsonb_array_elements_text(CASE jsonb_typeof(r.snapshot->'campuses') WHEN 'array' THEN r.snapshot->'campuses' ELSE '[]' END))`
+
+// snapshotFoundedPrecision reads the precision of the founding date out of
+// a snapshot. Snapshots taken before the precision was stored fall back to
+// the same guess the migration made for existing universities.
+const snapshotFoundedPrecision = `COALESCE(s.founded_precision, CASE WHEN date_trunc('year', s.founded) = s.founded THEN 'year' ELSE 'day' END)`
+
+// insertRevision stores a snapshot of the university row as it currently is
+// inside the transaction, keyed by its version. Campus names live in their
+// own table, so they are added to the snapshot separately.
+func insertRevision(ctx context.Context, tx *sql.Tx, universityID int64) error {
+	query := `
+		INSERT INTO university_revisions (university_id, version, snapshot)
+		SELECT id, version, to_jsonb(universities) || jsonb_build_object('campuses', ` + campusNames + `)
+		FROM universities
+		WHERE id = $1`
+
+	_, err := tx.ExecContext(ctx, query, universityID)
+	return err
+}
+
+type RevisionModel struct {
+	DB *sql.DB
+}
+
+func (m RevisionModel) Get(universityID int64, version int32) (*Revision, error) {
+	if universityID < 1 || version < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	// jsonb_populate_record turns the snapshot back into a universities row
+	query := `
+		SELECT r.version, r.created_at, s.id, s.created_at, s.name, COALESCE(s.slug, ''), s.founded, ` + snapshotFoundedPrecision + `, s.location, s.latitude, s.longitude, COALESCE(s.region_code, ''), COALESCE(s.province_code, ''), COALESCE(s.city_code, ''), COALESCE(s.institution_type, ''), COALESCE(s.status, 'active'), COALESCE(s.parent_id, 0), ` + snapshotCampuses + `, s.website, s.img_url, s.img_attribution, s.img_cite, s.version
+		FROM university_revisions r
+		CROSS JOIN LATERAL jsonb_populate_record(NULL::universities, r.snapshot) s
+		WHERE r.university_id = $1 AND r.version = $2`
+
+	revision := Revision{University: &University{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, universityID, version).Scan(
+		&revision.Version,
+		&revision.CreatedAt,
+		&revision.University.ID,
+		&revision.University.CreatedAt,
+		&revision.University.Name,
+		&revision.University.Slug,
+		&revision.University.Founded.Time,
+		&revision.University.Founded.Precision,
+		&revision.University.Location,
+		&revision.University.Latitude,
+		&revision.University.Longitude,
+		&revision.University.RegionCode,
+		&revision.University.ProvinceCode,
+		&revision.University.CityCode,
+		&revision.University.InstitutionType,
+		&revision.University.Status,
+		&revision.University.ParentID,
+		pq.Array(&revision.University.Campuses),
+		&revision.University.Website,
+		&revision.University.ImgURL,
+		&revision.University.ImgAttribution,
+		&revision.University.ImgCite,
+		&revision.University.Version)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &revision, nil
+}
+
+func (m RevisionModel) GetAll(universityID int64, filters Filters) ([]*Revision, Metadata, error) {
+	// versions are unique per university, so they need no tie breaker
+	sort := filters.sortFields()[0]
+
+	query := fmt.Sprintf(`
+	SELECT count(*) OVER(), r.version, r.created_at, s.id, s.created_at, s.name, COALESCE(s.slug, ''), s.founded, %[1]s, s.location, s.latitude, s.longitude, COALESCE(s.region_code, ''), COALESCE(s.province_code, ''), COALESCE(s.city_code, ''), COALESCE(s.institution_type, ''), COALESCE(s.status, 'active'), COALESCE(s.parent_id, 0), %[2]s, s.website, s.img_url, s.img_attribution, s.img_cite, s.version
+	FROM university_revisions r
+	CROSS JOIN LATERAL jsonb_populate_record(NULL::universities, r.snapshot) s
+	WHERE r.university_id = $1
+	ORDER BY r.%[3]s %[4]s
+	LIMIT $2 OFFSET $3`, snapshotFoundedPrecision, snapshotCampuses, sort.column, sort.direction)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, universityID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	revisions := []*Revision{}
+	totalRecords := 0
+
+	for rows.Next() {
+		revision := Revision{University: &University{}}
+		err := rows.Scan(
+			&totalRecords,
+			&revision.Version,
+			&revision.CreatedAt,
+			&revision.University.ID,
+			&revision.University.CreatedAt,
+			&revision.University.Name,
+			&revision.University.Slug,
+			&revision.University.Founded.Time,
+			&revision.University.Founded.Precision,
+			&revision.University.Location,
+			&revision.University.Latitude,
+			&revision.University.Longitude,
+			&revision.University.RegionCode,
+			&revision.University.ProvinceCode,
+			&revision.University.CityCode,
+			&revision.University.InstitutionType,
+			&revision.University.Status,
+			&revision.University.ParentID,
+			pq.Array(&revision.University.Campuses),
+			&revision.University.Website,
+			&revision.University.ImgURL,
+			&revision.University.ImgAttribution,
+			&revision.University.ImgCite,
+			&revision.University.Version)
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		revisions = append(revisions, &revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return revisions, metadata, nil
+}
+
+// GetAllForUniversities returns the version number and creation time of every
+// revision of each university, newest first, keyed by university id
+func (m RevisionModel) GetAllForUniversities(universityIDs []int64) (map[int64][]*Revision, error) {
+	query := `
+		SELECT university_id, version, created_at
+		FROM university_revisions
+		WHERE university_id = ANY($1)
+		ORDER BY university_id, version DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(universityIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// every university gets an entry, so that universities without
+	// revisions are encoded as an empty list rather than null
+	revisions := make(map[int64][]*Revision, len(universityIDs))
+	for _, id := range universityIDs {
+		revisions[id] = []*Revision{}
+	}
+
+	for rows.Next() {
+		var (
+			universityID int64
+			revision     Revision
+		)
+
+		err := rows.Scan(&universityID, &revision.Version, &revision.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions[universityID] = append(revisions[universityID], &revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Diff compares the snapshots of two versions of a university and returns the
+// fields whose values differ, keyed by column name
+func (m RevisionModel) Diff(universityID int64, from, to int32) (map[string]FieldChange, error) {
+	if universityID < 1 || from < 1 || to < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	// snapshots taken before a column was added don't have it, so the
+	// columns with defaults are filled in the same way as in Get. Otherwise
+	// they would show up as changed from null across the migration.
+	query := `
+		SELECT r.version, r.snapshot || jsonb_build_object(
+			'status', COALESCE(s.status, 'active'),
+			'founded_precision', ` + snapshotFoundedPrecision + `,
+			'campuses', to_jsonb(` + snapshotCampuses + `))
+		FROM university_revisions r
+		CROSS JOIN LATERAL jsonb_populate_record(NULL::universities, r.snapshot) s
+		WHERE r.university_id = $1 AND r.version IN ($2, $3)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, universityID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := make(map[int32]map[string]any)
+
+	for rows.Next() {
+		var (
+			version  int32
+			snapshot []byte
+		)
+
+		err := rows.Scan(&version, &snapshot)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := make(map[string]any)
+
+		err = json.Unmarshal(snapshot, &fields)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots[version] = fields
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	before, ok := snapshots[from]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	after, ok := snapshots[to]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	changes := make(map[string]FieldChange)
+
+	for key := range mergeKeys(before, after) {
+		// bookkeeping columns change between every pair of versions or
+		// aren't part of the record's content, and the slug follows the
+		// name, which is compared already
+		switch key {
+		case "id", "created_at", "version", "deleted_at", "slug":
+			continue
+		}
+
+		if !reflect.DeepEqual(before[key], after[key]) {
+			changes[key] = FieldChange{From: before[key], To: after[key]}
+		}
+	}
+
+	return changes, nil
+}
+
+func mergeKeys(a, b map[string]any) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a))
+
+	for key := range a {
+		keys[key] = struct{}{}
+	}
+
+	for key := range b {
+		keys[key] = struct{}{}
+	}
+
+	return keys
+}
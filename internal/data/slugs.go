@@ -0,0 +1,168 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SlugRX matches the slugs generated for universities
+var SlugRX = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// slugSeparatorRX matches the runs of characters that slugs replace with a
+// hyphen
+var slugSeparatorRX = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugAccents strips the accents found in Filipino and Spanish names. The
+// migration that added slugs does the same, so keep the two in step.
+var slugAccents = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u",
+	"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u",
+	"ñ", "n", "ü", "u")
+
+const maxSlugLength = 80
+
+// reservedSlugs are path segments that sit alongside slugs under
+// /v0/universities, so no university may take them
+var reservedSlugs = []string{"trash"}
+
+// Slugify turns a university name into the base of its slug, such as
+// "ateneo-de-manila-university". Names without letters get a "university"
+// prefix so that their slugs can't be mistaken for ids.
+func Slugify(name string) string {
+	slug := slugSeparatorRX.ReplaceAllString(slugAccents.Replace(strings.ToLower(name)), "-")
+	slug = strings.Trim(slug, "-")
+
+	if len(slug) > maxSlugLength {
+		slug = strings.Trim(slug[:maxSlugLength], "-")
+	}
+
+	if strings.Trim(slug, "0123456789") == "" {
+		slug = strings.Trim("university-"+slug, "-")
+	}
+
+	return slug
+}
+
+// uniqueSlug returns a slug for the university that no other university uses
+// now or used before, adding "-2", "-3" and so on to the base slug of its name
+// until one is free. Slugs the university itself used before may be taken
+// back.
+func uniqueSlug(ctx context.Context, tx *sql.Tx, university *University) (string, error) {
+	base := Slugify(university.Name)
+
+	// slugs are allocated one at a time, so that concurrent writes can't both
+	// settle on the same free slug. A lock per base slug wouldn't do, since a
+	// numbered slug such as "foo-2" can also be the base of another name.
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('university_slug'))`)
+	if err != nil {
+		return "", err
+	}
+
+	query := `
+		SELECT slug FROM universities
+		WHERE (slug = $1 OR slug LIKE $1 || '-%') AND id <> $2
+		UNION
+		SELECT slug FROM university_slugs
+		WHERE (slug = $1 OR slug LIKE $1 || '-%') AND university_id <> $2`
+
+	rows, err := tx.QueryContext(ctx, query, base, university.ID)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	taken := map[string]bool{}
+
+	for _, slug := range reservedSlugs {
+		taken[slug] = true
+	}
+
+	for rows.Next() {
+		var slug string
+
+		err := rows.Scan(&slug)
+		if err != nil {
+			return "", err
+		}
+
+		taken[slug] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	slug := base
+	for n := 2; taken[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	return slug, nil
+}
+
+// moveSlug gives the university a new slug after its name has changed. The
+// old slug is kept so that links using it can still be redirected.
+func moveSlug(ctx context.Context, tx *sql.Tx, university *University, oldSlug string) error {
+	if university.Slug == oldSlug {
+		return nil
+	}
+
+	query := `
+		INSERT INTO university_slugs (slug, university_id)
+		VALUES ($1, $2)
+		ON CONFLICT (slug) DO NOTHING`
+
+	_, err := tx.ExecContext(ctx, query, oldSlug, university.ID)
+	if err != nil {
+		return err
+	}
+
+	// a slug taken back is current again, so it no longer redirects
+	query = `
+		DELETE FROM university_slugs
+		WHERE slug = $1 AND university_id = $2`
+
+	_, err = tx.ExecContext(ctx, query, university.Slug, university.ID)
+	return err
+}
+
+// ResolveSlug returns the id of the university known by the given slug,
+// along with the university's current slug, which differs from the given
+// one when the slug is outdated
+func (m UniversityModel) ResolveSlug(slug string) (int64, string, error) {
+	query := `
+		SELECT id, slug
+		FROM universities
+		WHERE slug = $1 AND deleted_at IS NULL
+		UNION ALL
+		SELECT u.id, u.slug
+		FROM university_slugs s
+		INNER JOIN universities u ON u.id = s.university_id
+		WHERE s.slug = $1 AND u.deleted_at IS NULL
+		LIMIT 1`
+
+	var (
+		id      int64
+		current string
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, slug).Scan(&id, &current)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, "", ErrRecordNotFound
+		default:
+			return 0, "", err
+		}
+	}
+
+	return id, current, nil
+}
@@ -0,0 +1,45 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Ateneo de Manila University", "ateneo-de-manila-university"},
+		{"University of the Philippines Diliman", "university-of-the-philippines-diliman"},
+		{"  De La Salle University  ", "de-la-salle-university"},
+		{"Colegio de San Juan de Letrán", "colegio-de-san-juan-de-letran"},
+		{"Universidad de Sta. Isabel – Naga", "universidad-de-sta-isabel-naga"},
+		{"Parañaque City College", "paranaque-city-college"},
+		{"St. Paul University (Dumaguete)", "st-paul-university-dumaguete"},
+		{"AMA Computer College—Las Piñas", "ama-computer-college-las-pinas"},
+		{"1987", "university-1987"},
+		{"---", "university"},
+		{"", "university"},
+		{strings.Repeat("a", 79) + " b", strings.Repeat("a", 79)},
+		{strings.Repeat("abc ", 30), strings.TrimSuffix(strings.Repeat("abc-", 20), "-")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Slugify(tt.name)
+
+			if got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+
+			if !SlugRX.MatchString(got) {
+				t.Errorf("Slugify(%q) = %q, which doesn't match SlugRX", tt.name, got)
+			}
+
+			if len(got) > maxSlugLength {
+				t.Errorf("Slugify(%q) = %q, which is longer than %d bytes", tt.name, got, maxSlugLength)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/liamgluna/kolehiyo/internal/validator"
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateTuitionFee = errors.New("duplicate tuition fee")
+
+const (
+	TuitionUnitPerUnit     = "per_unit"
+	TuitionUnitPerSemester = "per_semester"
+)
+
+var TuitionUnits = []string{TuitionUnitPerUnit, TuitionUnitPerSemester}
+
+// latestTuition is the university wide tuition per semester in the most
+// recent academic year on record, used to sort universities by cost
+const latestTuition = `(SELECT t.amount FROM tuition_fees t WHERE t.university_id = universities.id AND t.program_id IS NULL AND t.unit = 'per_semester' ORDER BY t.academic_year DESC LIMIT 1)`
+
+type TuitionModel struct {
+	DB *sql.DB
+}
+
+type TuitionFee struct {
+	ID             int64     `json:"id"`
+	CreatedAt      time.Time `json:"-"`
+	UniversityID   int64     `json:"university_id"`
+	UniversityName string    `json:"university_name,omitempty"`
+	// ProgramID is set when the fee only applies to a single program
+	ProgramID    int64        `json:"program_id,omitempty"`
+	AcademicYear AcademicYear `json:"academic_year"`
+	Unit         string       `json:"unit"`
+	// Amount is in centavos, so that no precision is lost to floats
+	Amount  int64 `json:"amount_centavos"`
+	Version int32 `json:"version"`
+}
+
+func ValidateTuitionFee(v *validator.Validator, fee *TuitionFee) {
+	ValidateAcademicYear(v, "academic_year", fee.AcademicYear)
+
+	v.Check(validator.PermittedValue(fee.Unit, TuitionUnits...), "unit", "must be one of "+strings.Join(TuitionUnits, ", "))
+
+	v.Check(fee.Amount >= 0, "amount_centavos", "must not be negative")
+}
+
+// TuitionQuery holds the filters that narrow down the fees returned by GetAll
+// and GetSeries. Zero values leave the corresponding filter unapplied.
+type TuitionQuery struct {
+	UniversityID int64
+	ProgramID    int64
+	AcademicYear AcademicYear
+	Unit         string
+}
+
+func ValidateTuitionQuery(v *validator.Validator, q TuitionQuery) {
+	v.Check(q.ProgramID >= 0, "program_id", "must be a positive integer")
+
+	if q.Unit != "" {
+		v.Check(validator.PermittedValue(q.Unit, TuitionUnits...), "unit", "must be one of "+strings.Join(TuitionUnits, ", "))
+	}
+}
+
+func (m TuitionModel) Insert(fee *TuitionFee) error {
+	query := `
+		INSERT INTO tuition_fees (university_id, program_id, academic_year, unit, amount)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, version`
+
+	args := []any{fee.UniversityID, nullInt64(fee.ProgramID), fee.AcademicYear, fee.Unit, fee.Amount}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&fee.ID, &fee.CreatedAt, &fee.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "tuition_fees_key"`:
+			return ErrDuplicateTuitionFee
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the fee with the given id, as long as its university hasn't
+// been deleted
+func (m TuitionModel) Get(id int64) (*TuitionFee, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT t.id, t.created_at, t.university_id, u.name, COALESCE(t.program_id, 0), t.academic_year, t.unit, t.amount, t.version
+		FROM tuition_fees t
+		INNER JOIN universities u ON u.id = t.university_id
+		WHERE t.id = $1 AND u.deleted_at IS NULL`
+
+	var fee TuitionFee
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&fee.ID,
+		&fee.CreatedAt,
+		&fee.UniversityID,
+		&fee.UniversityName,
+		&fee.ProgramID,
+		&fee.AcademicYear,
+		&fee.Unit,
+		&fee.Amount,
+		&fee.Version)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &fee, nil
+}
+
+func (m TuitionModel) Update(fee *TuitionFee) error {
+	// version is used to implement optimistic concurrency control
+	query := `
+		UPDATE tuition_fees
+		SET program_id = $1, academic_year = $2, unit = $3, amount = $4, version = version + 1
+		WHERE id = $5 AND version = $6
+		RETURNING version`
+
+	args := []any{
+		nullInt64(fee.ProgramID),
+		fee.AcademicYear,
+		fee.Unit,
+		fee.Amount,
+		fee.ID,
+		fee.Version}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&fee.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "tuition_fees_key"`:
+			return ErrDuplicateTuitionFee
+		// sql.ErrNoRows in this case means that there was an edit conflict
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m TuitionModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM tuition_fees
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAll returns the fees matching the query across every university that
+// hasn't been deleted
+func (m TuitionModel) GetAll(q TuitionQuery, filters Filters) ([]*TuitionFee, Metadata, error) {
+	sortFields := filters.sortFields()
+	sortKeys := make([]string, len(sortFields))
+
+	for i, field := range sortFields {
+		sortKeys[i] = fmt.Sprintf("(%s)::text", field.column)
+	}
+
+	keysetCond, orderBy, keysetArgs := filters.keyset(sortFields, 7)
+
+	// the join happens in a subquery so that the keyset clauses can refer to
+	// the columns without qualifying them
+	query := fmt.Sprintf(`
+	SELECT count(*) OVER(), id, created_at, university_id, university_name, COALESCE(program_id, 0), academic_year, unit, amount, version, ARRAY[%s]
+	FROM (
+		SELECT t.*, u.name AS university_name
+		FROM tuition_fees t
+		INNER JOIN universities u ON u.id = t.university_id
+		WHERE u.deleted_at IS NULL
+	) AS tuition_fees
+	WHERE (university_id = $1 OR $1 = 0)
+	AND (program_id = $2 OR $2 = 0)
+	AND (academic_year = $3 OR $3 = 0)
+	AND (unit = $4 OR $4 = '')
+	AND %s
+	ORDER BY %s
+	LIMIT $5 OFFSET $6`, strings.Join(sortKeys, ", "), keysetCond, orderBy)
+
+	args := []any{q.UniversityID, q.ProgramID, q.AcademicYear, q.Unit, filters.limit(), filters.offset()}
+	args = append(args, keysetArgs...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	fees := []*TuitionFee{}
+	keys := []keysetKey{}
+	totalRecords := 0
+
+	for rows.Next() {
+		var (
+			fee        TuitionFee
+			sortValues []string
+		)
+
+		err := rows.Scan(
+			&totalRecords,
+			&fee.ID,
+			&fee.CreatedAt,
+			&fee.UniversityID,
+			&fee.UniversityName,
+			&fee.ProgramID,
+			&fee.AcademicYear,
+			&fee.Unit,
+			&fee.Amount,
+			&fee.Version,
+			pq.Array(&sortValues))
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		fees = append(fees, &fee)
+		keys = append(keys, keysetKey{ID: fee.ID, Values: sortValues})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	fees, metadata := keysetPage(filters, fees, keys, totalRecords)
+
+	return fees, metadata, nil
+}
+
+// TuitionPoint is the fee in a single academic year, along with the change
+// from the year before it when that year is on record
+type TuitionPoint struct {
+	AcademicYear  AcademicYear `json:"academic_year"`
+	Amount        int64        `json:"amount_centavos"`
+	ChangePercent *float64     `json:"change_percent,omitempty"`
+}
+
+// TuitionSeries is the history of a single fee of a university, which is
+// either university wide or for one program
+type TuitionSeries struct {
+	ProgramID   int64           `json:"program_id,omitempty"`
+	ProgramName string          `json:"program_name,omitempty"`
+	Unit        string          `json:"unit"`
+	Points      []*TuitionPoint `json:"points"`
+}
+
+// GetSeries returns the history of every fee of a university matching the
+// query, oldest year first. University wide fees come before program fees.
+func (m TuitionModel) GetSeries(q TuitionQuery) ([]*TuitionSeries, error) {
+	query := `
+		SELECT COALESCE(t.program_id, 0), COALESCE(p.name, ''), t.unit, t.academic_year, t.amount
+		FROM tuition_fees t
+		LEFT JOIN programs p ON p.id = t.program_id
+		WHERE t.university_id = $1
+		AND (t.program_id = $2 OR $2 = 0)
+		AND (t.unit = $3 OR $3 = '')
+		ORDER BY t.program_id NULLS FIRST, t.unit, t.academic_year`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, q.UniversityID, q.ProgramID, q.Unit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := []*TuitionSeries{}
+
+	var current *TuitionSeries
+
+	for rows.Next() {
+		var (
+			s     TuitionSeries
+			point TuitionPoint
+		)
+
+		err := rows.Scan(&s.ProgramID, &s.ProgramName, &s.Unit, &point.AcademicYear, &point.Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		// the rows of a series are next to each other
+		if current == nil || current.ProgramID != s.ProgramID || current.Unit != s.Unit {
+			current = &s
+			current.Points = []*TuitionPoint{}
+			series = append(series, current)
+		}
+
+		if n := len(current.Points); n > 0 {
+			prev := current.Points[n-1]
+			point.ChangePercent = percentChange(prev.AcademicYear, prev.Amount, point.AcademicYear, point.Amount)
+		}
+
+		current.Points = append(current.Points, &point)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
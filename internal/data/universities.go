@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/liamgluna/kolehiyo/internal/validator"
@@ -19,75 +21,493 @@ type University struct {
 	ID        int64     `json:"id"`
 	CreatedAt time.Time `json:"-"`
 	Name      string    `json:"name"`
-	Founded   Date      `json:"founded"`
-	Location  string    `json:"location"`
-	Campuses  []string  `json:"campuses,omitempty"`
-	Website   string    `json:"website"`
-	ImgURL   string    `json:"img_url,omitempty"`
-	ImgCite   string    `json:"img_cite,omitempty"`
-	Version   int32     `json:"version"`
+	// Slug is generated from the name and can be used in place of the id
+	// when fetching the university
+	Slug      string   `json:"slug,omitempty"`
+	Founded   Date     `json:"founded"`
+	Location  string   `json:"location"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	// the PSGC codes of the region, province and city or municipality
+	RegionCode   string `json:"region_code,omitempty"`
+	ProvinceCode string `json:"province_code,omitempty"`
+	CityCode     string `json:"city_code,omitempty"`
+	// InstitutionType is left empty for universities not yet classified
+	InstitutionType string `json:"institution_type,omitempty"`
+	Status          string `json:"status"`
+	// ParentID is the university system this university is a constituent of
+	ParentID int64    `json:"parent_id,omitempty"`
+	Campuses []string `json:"campuses,omitempty"`
+	Website  string   `json:"website"`
+	ImgURL   string   `json:"img_url,omitempty"`
+	// ImgAttribution is required for images set since attributions were
+	// recorded
+	ImgAttribution *ImageAttribution `json:"img_attribution,omitempty"`
+	// ImgCite is the credit line rendered from ImgAttribution, kept for
+	// clients that predate it. Images added before attributions were
+	// structured keep their free text citation until one is given.
+	ImgCite   string     `json:"img_cite,omitempty"`
+	Version   int32      `json:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Score     float64    `json:"score,omitempty"`
+	// Distance is only set when searching near a point
+	Distance *float64 `json:"distance_km,omitempty"`
 }
 
-func ValidateUniversity(v *validator.Validator, university *University) {
+// InstitutionTypes classify universities by ownership: state universities
+// and colleges, local universities and colleges, private non-sectarian and
+// sectarian schools, and other government schools
+var InstitutionTypes = []string{"suc", "luc", "private_non_sectarian", "private_sectarian", "other_government"}
+
+// UniversityStatuses tell whether a university still operates on its own
+var UniversityStatuses = []string{"active", "merged", "closed"}
+
+// UniversityFields are the fields that can be requested in a sparse
+// fieldset. Each one is stored in the column of the same name.
+var UniversityFields = []string{"id", "name", "slug", "founded", "location", "latitude", "longitude", "region_code", "province_code", "city_code", "institution_type", "status", "parent_id", "campuses", "website", "img_url", "img_attribution", "img_cite", "version"}
+
+// UniversityIncludes are the related collections that can be embedded in a
+// university response
+var UniversityIncludes = []string{"names", "programs", "versions"}
+
+// foundedEnd is the first day after the period a founding date covers
+const foundedEnd = `(founded + CASE founded_precision WHEN 'year' THEN interval '1 year' WHEN 'month' THEN interval '1 month' ELSE interval '1 day' END)`
+
+// foundedKey writes a founding date at its precision, for sorting. Partial
+// dates sort like ISO 8601 strings, so a year comes before its months and
+// a month before its days.
+const foundedKey = `(CASE founded_precision WHEN 'year' THEN to_char(founded, 'YYYY') WHEN 'month' THEN to_char(founded, 'YYYY-MM') ELSE to_char(founded, 'YYYY-MM-DD') END COLLATE "C")`
+
+// campusNames selects the names of a university's campuses in the order they
+// were added, which is how the campuses field has always been presented
+const campusNames = `ARRAY(SELECT c.name FROM campuses c WHERE c.university_id = universities.id ORDER BY c.id)`
+
+// scanFields returns the columns to select for the requested fields and the
+// destinations in u to scan them into. id and created_at are always
+// selected, and an empty fieldset selects every field.
+func (u *University) scanFields(fields []string) (string, []any) {
+	if len(fields) == 0 {
+		fields = UniversityFields
+	}
+
+	columns := []string{"id", "created_at"}
+	dest := []any{&u.ID, &u.CreatedAt}
+
+	for _, field := range fields {
+		column := field
+
+		switch field {
+		case "id":
+			continue
+		case "name":
+			dest = append(dest, &u.Name)
+		case "slug":
+			dest = append(dest, &u.Slug)
+		case "founded":
+			column = "founded, founded_precision"
+			dest = append(dest, &u.Founded.Time, &u.Founded.Precision)
+		case "location":
+			dest = append(dest, &u.Location)
+		case "latitude":
+			dest = append(dest, &u.Latitude)
+		case "longitude":
+			dest = append(dest, &u.Longitude)
+		case "region_code":
+			column = "COALESCE(region_code, '') AS region_code"
+			dest = append(dest, &u.RegionCode)
+		case "province_code":
+			column = "COALESCE(province_code, '') AS province_code"
+			dest = append(dest, &u.ProvinceCode)
+		case "city_code":
+			column = "COALESCE(city_code, '') AS city_code"
+			dest = append(dest, &u.CityCode)
+		case "institution_type":
+			column = "COALESCE(institution_type, '') AS institution_type"
+			dest = append(dest, &u.InstitutionType)
+		case "status":
+			dest = append(dest, &u.Status)
+		case "parent_id":
+			column = "COALESCE(parent_id, 0) AS parent_id"
+			dest = append(dest, &u.ParentID)
+		case "campuses":
+			column = campusNames + " AS campuses"
+			dest = append(dest, pq.Array(&u.Campuses))
+		case "website":
+			dest = append(dest, &u.Website)
+		case "img_url":
+			dest = append(dest, &u.ImgURL)
+		case "img_attribution":
+			dest = append(dest, &u.ImgAttribution)
+		case "img_cite":
+			dest = append(dest, &u.ImgCite)
+		case "version":
+			dest = append(dest, &u.Version)
+		default:
+			panic("unknown university field: " + field)
+		}
+
+		columns = append(columns, column)
+	}
+
+	return strings.Join(columns, ", "), dest
+}
+
+// ValidateFieldset checks that a sparse fieldset or include list only
+// contains values from the safelist, reporting the first one that doesn't
+func ValidateFieldset(v *validator.Validator, key string, values, safelist []string) {
+	for _, value := range values {
+		if !validator.PermittedValue(value, safelist...) {
+			v.AddError(key, fmt.Sprintf("unknown value %q", value))
+			return
+		}
+	}
+
+	v.Check(validator.Unique(values), key, "must not contain duplicate values")
+}
+
+// ValidateUniversity checks a university about to be written. previousImgURL
+// is the image the university had before, or empty for a new university.
+func ValidateUniversity(v *validator.Validator, university *University, previousImgURL string) {
 	v.Check(university.Name != "", "name", "must be provided")
 	v.Check(len(university.Name) <= 150, "name", "must not be more than 150 bytes long")
 
-	founded := time.Time(university.Founded)
+	founded := university.Founded.Time
 	v.Check(!founded.IsZero(), "founded", "must be provided")
+	v.Check(founded.IsZero() || validator.PermittedValue(university.Founded.Precision, DatePrecisions...), "founded", "must be a year, a year and month, or a full date")
 	v.Check(founded.Year() >= 1589, "founded", "must be greater than or equal to 1589")
 	v.Check(founded.Year() <= time.Now().Year(), "founded", "must be less than or equal to the current year")
 
 	v.Check(university.Location != "", "location", "must be provided")
 	v.Check(len(university.Location) <= 500, "location", "must not be more than 500 bytes long")
 
+	ValidateCoordinates(v, "latitude", "longitude", university.Latitude, university.Longitude)
+
+	ValidatePSGC(v, "region_code", university.RegionCode)
+	ValidatePSGC(v, "province_code", university.ProvinceCode)
+	ValidatePSGC(v, "city_code", university.CityCode)
+
+	if university.InstitutionType != "" {
+		v.Check(validator.PermittedValue(university.InstitutionType, InstitutionTypes...), "institution_type", "must be one of "+strings.Join(InstitutionTypes, ", "))
+	}
+
+	v.Check(validator.PermittedValue(university.Status, UniversityStatuses...), "status", "must be one of "+strings.Join(UniversityStatuses, ", "))
+
+	// parents that are descendants of the university are caught on write,
+	// since finding them takes a walk up the hierarchy
+	v.Check(university.ParentID >= 0, "parent_id", "must be a positive integer")
+	v.Check(university.ParentID == 0 || university.ParentID != university.ID, "parent_id", "must not be the university itself")
+
 	v.Check(university.Website != "", "website", "must be provided")
 	v.Check(len(university.Website) <= 100, "website", "must not be more than 100 bytes long")
 
 	v.Check(validator.Unique(university.Campuses), "campuses", "must not contain duplicate values")
+
+	v.Check(len(university.ImgURL) <= 500, "img_url", "must not be more than 500 bytes long")
+
+	// an image can only be shown with credit to its author and under the
+	// terms of its license. Images set before attributions were recorded
+	// only have an img_cite, so they are kept as they are until replaced.
+	if university.ImgURL != "" && university.ImgURL != previousImgURL {
+		v.Check(university.ImgAttribution != nil, "img_attribution", "must be provided when img_url is set")
+	}
+
+	if university.ImgAttribution != nil {
+		ValidateImageAttribution(v, university.ImgAttribution)
+	}
+}
+
+// setImgCite renders img_cite from the image attribution. A university without
+// an image has no attribution or citation either.
+func (u *University) setImgCite() {
+	switch {
+	case u.ImgURL == "":
+		u.ImgAttribution, u.ImgCite = nil, ""
+	case u.ImgAttribution != nil:
+		u.ImgCite = u.ImgAttribution.String()
+	}
+}
+
+// UniversityQuery holds the filters that narrow down the universities
+// returned by GetAll. Zero values leave the corresponding filter unapplied.
+type UniversityQuery struct {
+	// Fields restricts the columns fetched to a sparse fieldset
+	Fields     []string
+	Name       string
+	SearchMode string
+	Location   string
+	Campus     string
+	// FoundedFrom and FoundedTo match universities whose founding date
+	// could fall within them, given the precision it is known to
+	FoundedFrom Date
+	FoundedTo   Date
+	// Near limits the results to universities within RadiusKm of a point
+	Near            *Coordinates
+	RadiusKm        float64
+	RegionCode      string
+	ProvinceCode    string
+	CityCode        string
+	InstitutionType string
+	Status          string
+	// IncludeChildren makes the name also match the constituent units of the
+	// universities it names, however deep they are in the hierarchy
+	IncludeChildren bool
+}
+
+const (
+	SearchModeFullText = "fulltext"
+	SearchModeFuzzy    = "fuzzy"
+)
+
+func ValidateUniversityQuery(v *validator.Validator, q UniversityQuery) {
+	ValidateFieldset(v, "fields", q.Fields, UniversityFields)
+
+	v.Check(len(q.Name) <= 150, "name", "must not be more than 150 bytes long")
+	v.Check(validator.PermittedValue(q.SearchMode, SearchModeFullText, SearchModeFuzzy), "search_mode", "must be fulltext or fuzzy")
+	v.Check(len(q.Location) <= 500, "location", "must not be more than 500 bytes long")
+	v.Check(len(q.Campus) <= 150, "campus", "must not be more than 150 bytes long")
+
+	if from := q.FoundedFrom.Time; !from.IsZero() {
+		v.Check(from.Year() >= 1589, "founded_from", "must be greater than or equal to 1589")
+		v.Check(from.Year() <= time.Now().Year(), "founded_from", "must be less than or equal to the current year")
+	}
+
+	if to := q.FoundedTo.Time; !to.IsZero() {
+		v.Check(to.Year() >= 1589, "founded_to", "must be greater than or equal to 1589")
+		v.Check(to.Year() <= time.Now().Year(), "founded_to", "must be less than or equal to the current year")
+	}
+
+	if !q.FoundedFrom.Time.IsZero() && !q.FoundedTo.Time.IsZero() {
+		v.Check(!q.FoundedFrom.Time.After(q.FoundedTo.Time), "founded_from", "must be less than or equal to founded_to")
+	}
+
+	if q.Near != nil {
+		ValidateCoordinates(v, "near", "near", &q.Near.Latitude, &q.Near.Longitude)
+		v.Check(q.RadiusKm > 0, "radius_km", "must be greater than zero")
+		v.Check(q.RadiusKm <= MaxRadiusKm, "radius_km", fmt.Sprintf("must be a maximum of %d", MaxRadiusKm))
+	}
+
+	ValidatePSGC(v, "region", q.RegionCode)
+	ValidatePSGC(v, "province", q.ProvinceCode)
+	ValidatePSGC(v, "city", q.CityCode)
+
+	if q.InstitutionType != "" {
+		v.Check(validator.PermittedValue(q.InstitutionType, InstitutionTypes...), "institution_type", "must be one of "+strings.Join(InstitutionTypes, ", "))
+	}
+
+	if q.Status != "" {
+		v.Check(validator.PermittedValue(q.Status, UniversityStatuses...), "status", "must be one of "+strings.Join(UniversityStatuses, ", "))
+	}
+}
+
+// nearPoint returns the latitude and longitude to search near and the radius
+// in metres, or nils when the search isn't limited to a point
+func (q UniversityQuery) nearPoint() (latitude, longitude, radius any) {
+	if q.Near == nil {
+		return nil, nil, nil
+	}
+
+	return q.Near.Latitude, q.Near.Longitude, q.RadiusKm * 1000
+}
+
+// foundedRange converts the founding date filters into the first day of
+// FoundedFrom and the first day after FoundedTo, or nil when a bound is unset
+func (q UniversityQuery) foundedRange() (from, to any) {
+	if !q.FoundedFrom.Time.IsZero() {
+		from = q.FoundedFrom.Time
+	}
+
+	if !q.FoundedTo.Time.IsZero() {
+		to = q.FoundedTo.End()
+	}
+
+	return from, to
+}
+
+// where returns the WHERE conditions selecting the universities matching
+// the query, and the parameters they use starting at $1. The campus filter
+// is a case-insensitive match against the name or city of any of the
+// university's campuses. The earth_box check is a bounding box prefilter
+// that can use the index, and the distance check trims it down to a circle.
+// A founding date matches the founded range when the period it covers
+// overlaps the range. Including children walks up from each university to its ancestors and
+// checks them against the name, reusing the name condition on an unaliased
+// universities table so that it refers to the ancestor.
+func (q UniversityQuery) where() (string, []any) {
+	nameCond, locationCond, _ := q.textSearch()
+
+	where := fmt.Sprintf(`(%[1]s OR $1 = '' OR ($14 AND EXISTS (
+		WITH RECURSIVE ancestors (ancestor_id) AS (
+			SELECT p.parent_id FROM universities p WHERE p.id = universities.id
+			UNION
+			SELECT p.parent_id FROM universities p INNER JOIN ancestors a ON p.id = a.ancestor_id
+		)
+		SELECT 1
+		FROM universities
+		INNER JOIN ancestors a ON a.ancestor_id = universities.id
+		WHERE universities.deleted_at IS NULL AND %[1]s)))
+	AND (%[2]s OR $2 = '')
+	AND (%[3]s > $3 OR $3 IS NULL)
+	AND (founded < $4 OR $4 IS NULL)
+	AND (EXISTS (SELECT 1 FROM campuses c WHERE c.university_id = universities.id AND (lower(c.name) = lower($5) OR lower(c.city) = lower($5))) OR $5 = '')
+	AND ($6::float8 IS NULL OR (
+		latitude IS NOT NULL
+		AND earth_box(ll_to_earth($6, $7), $8) @> ll_to_earth(latitude, longitude)
+		AND earth_distance(ll_to_earth($6, $7), ll_to_earth(latitude, longitude)) <= $8))
+	AND (region_code = $9 OR $9 = '')
+	AND (province_code = $10 OR $10 = '')
+	AND (city_code = $11 OR $11 = '')
+	AND (institution_type = $12 OR $12 = '')
+	AND (status = $13 OR $13 = '')
+	AND deleted_at IS NULL`, nameCond, locationCond, foundedEnd)
+
+	foundedFrom, foundedTo := q.foundedRange()
+	latitude, longitude, radius := q.nearPoint()
+
+	args := []any{
+		q.Name,
+		q.Location,
+		foundedFrom,
+		foundedTo,
+		q.Campus,
+		latitude,
+		longitude,
+		radius,
+		q.RegionCode,
+		q.ProvinceCode,
+		q.CityCode,
+		q.InstitutionType,
+		q.Status,
+		q.IncludeChildren}
+
+	return where, args
+}
+
+// textSearch returns the SQL conditions used for the name ($1) and location ($2)
+// filters, and the expression used to score how relevant a row is to the name.
+// Fuzzy mode uses trigram similarity so that typos and partial words still match.
+// The name also matches a university's alternate names, and scores as well
+// as the best of them, without the university being listed more than once.
+func (q UniversityQuery) textSearch() (nameCond, locationCond, score string) {
+	match := func(column, param string) string {
+		if q.SearchMode == SearchModeFuzzy {
+			return "(" + column + " % " + param + " OR " + param + " <% " + column + ")"
+		}
+
+		return "to_tsvector('simple', " + column + ") @@ plainto_tsquery('simple', " + param + ")"
+	}
+
+	rank := func(column string) string {
+		if q.SearchMode == SearchModeFuzzy {
+			return "GREATEST(similarity(" + column + ", $1), word_similarity($1, " + column + "))"
+		}
+
+		return "ts_rank(to_tsvector('simple', " + column + "), plainto_tsquery('simple', $1))"
+	}
+
+	nameCond = fmt.Sprintf("(%s OR EXISTS (SELECT 1 FROM university_names n WHERE n.university_id = universities.id AND %s))", match("name", "$1"), match("n.name", "$1"))
+	locationCond = match("location", "$2")
+
+	// GREATEST ignores the NULL of a university without alternate names
+	score = fmt.Sprintf("GREATEST(%s, (SELECT max(%s) FROM university_names n WHERE n.university_id = universities.id))", rank("name"), rank("n.name"))
+
+	return nameCond, locationCond, score
 }
 
-func (m UniversityModel) Insert(university *University) error {
+func (m UniversityModel) Insert(university *University, info AuditInfo) error {
 	query := `
-		INSERT INTO universities (name, founded, location, campuses, website, img_url, img_cite)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO universities (name, founded, location, latitude, longitude, region_code, province_code, city_code, institution_type, status, website, img_url, img_cite, parent_id, founded_precision, img_attribution, slug)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12, $13, $14, $15, $16, $17)
 		RETURNING id, created_at, version`
 
-	args := []any{university.Name, time.Time(university.Founded), university.Location, pq.Array(university.Campuses), university.Website, university.ImgURL, university.ImgCite}
-
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&university.ID, &university.CreatedAt, &university.Version)
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback is a no-op once the transaction has been committed
+	defer tx.Rollback()
+
+	err = resolvePlace(ctx, tx, university)
+	if err != nil {
+		return err
+	}
+
+	err = checkParent(ctx, tx, university)
+	if err != nil {
+		return err
+	}
+
+	university.Slug, err = uniqueSlug(ctx, tx, university)
+	if err != nil {
+		return err
+	}
+
+	university.setImgCite()
+
+	args := []any{
+		university.Name,
+		university.Founded.Time,
+		university.Location,
+		university.Latitude,
+		university.Longitude,
+		university.RegionCode,
+		university.ProvinceCode,
+		university.CityCode,
+		university.InstitutionType,
+		university.Status,
+		university.Website,
+		university.ImgURL,
+		university.ImgCite,
+		nullInt64(university.ParentID),
+		university.Founded.Precision,
+		university.ImgAttribution,
+		university.Slug}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&university.ID, &university.CreatedAt, &university.Version)
+	if err != nil {
+		return err
+	}
+
+	err = syncCampusNames(ctx, tx, university.ID, university.Campuses)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditEvent(ctx, tx, AuditActionInsert, university.ID, nil, university, info)
+	if err != nil {
+		return err
+	}
+
+	err = insertRevision(ctx, tx, university.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (m UniversityModel) Get(id int64) (*University, error) {
+// Get returns the university with the given id. When fields are given, only
+// those fields (plus id) are fetched and the rest are left at their zero value.
+func (m UniversityModel) Get(id int64, fields ...string) (*University, error) {
 	if id < 1 {
 		return nil, ErrRecordNotFound
 	}
 
-	query := `
-		SELECT id, created_at, name, founded, location, campuses, website, img_url, img_cite, version
-		FROM universities
-		WHERE id = $1`
-
 	var university University
 
+	columns, dest := university.scanFields(fields)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM universities
+		WHERE id = $1 AND deleted_at IS NULL`, columns)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	row := m.DB.QueryRowContext(ctx, query, id)
-	err := row.Scan(
-		&university.ID,
-		&university.CreatedAt,
-		&university.Name,
-		&university.Founded,
-		&university.Location,
-		pq.Array(&university.Campuses),
-		&university.Website,
-		&university.ImgURL,
-		&university.ImgCite,
-		&university.Version)
-
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(dest...)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -100,29 +520,87 @@ func (m UniversityModel) Get(id int64) (*University, error) {
 	return &university, nil
 }
 
-func (m UniversityModel) Update(university *University) error {
+func (m UniversityModel) Update(university *University, info AuditInfo) error {
 	// version is used to implement optimistic concurrency control
 	query := `
 		UPDATE universities
-		SET name = $1, founded = $2, location = $3, campuses = $4, website = $5, img_url = $6, img_cite = $7, version = version + 1
-		WHERE id = $8 AND version = $9
+		SET name = $1, founded = $2, location = $3, latitude = $4, longitude = $5,
+			region_code = NULLIF($6, ''), province_code = NULLIF($7, ''), city_code = NULLIF($8, ''),
+			institution_type = NULLIF($9, ''), status = $10,
+			website = $11, img_url = $12, img_cite = $13, parent_id = $14, founded_precision = $15, img_attribution = $16, slug = $17, version = version + 1
+		WHERE id = $18 AND version = $19 AND deleted_at IS NULL
 		RETURNING version`
 
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := m.getForUpdate(ctx, tx, university.ID, false)
+	if err != nil {
+		switch {
+		// the record was deleted since the client fetched it
+		case errors.Is(err, ErrRecordNotFound):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	err = resolvePlace(ctx, tx, university)
+	if err != nil {
+		return err
+	}
+
+	err = checkParent(ctx, tx, university)
+	if err != nil {
+		return err
+	}
+
+	// the slug only follows the name, so that links keep working for as long
+	// as the name does
+	if university.Name != before.Name {
+		university.Slug, err = uniqueSlug(ctx, tx, university)
+		if err != nil {
+			return err
+		}
+
+		err = moveSlug(ctx, tx, university, before.Slug)
+		if err != nil {
+			return err
+		}
+	} else {
+		university.Slug = before.Slug
+	}
+
+	university.setImgCite()
+
 	args := []any{
 		university.Name,
-		time.Time(university.Founded),
+		university.Founded.Time,
 		university.Location,
-		pq.Array(university.Campuses),
+		university.Latitude,
+		university.Longitude,
+		university.RegionCode,
+		university.ProvinceCode,
+		university.CityCode,
+		university.InstitutionType,
+		university.Status,
 		university.Website,
 		university.ImgURL,
 		university.ImgCite,
+		nullInt64(university.ParentID),
+		university.Founded.Precision,
+		university.ImgAttribution,
+		university.Slug,
 		university.ID,
 		university.Version}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&university.Version)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&university.Version)
 	if err != nil {
 		switch {
 		// sql.ErrNoRows in this case means that there was an edit conflict
@@ -133,50 +611,238 @@ func (m UniversityModel) Update(university *University) error {
 		}
 	}
 
-	return nil
+	// campuses left nil weren't sent, so they stay as they are rather than
+	// removing any added since the university was fetched
+	if university.Campuses == nil {
+		university.Campuses = before.Campuses
+	} else {
+		err = syncCampusNames(ctx, tx, university.ID, university.Campuses)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = insertAuditEvent(ctx, tx, AuditActionUpdate, university.ID, before, university, info)
+	if err != nil {
+		return err
+	}
+
+	err = insertRevision(ctx, tx, university.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (m UniversityModel) Delete(id int64) error {
+// Delete moves the university to the trash. It can be brought back with
+// Restore until it is purged.
+func (m UniversityModel) Delete(id int64, info AuditInfo) error {
 	if id < 1 {
 		return ErrRecordNotFound
 	}
 
 	query := `
-		DELETE FROM universities
-		WHERE id = $1`
+		UPDATE universities
+		SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	result, err := m.DB.ExecContext(ctx, query, id)
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	before, err := m.getForUpdate(ctx, tx, id, false)
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return ErrRecordNotFound
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	err = insertAuditEvent(ctx, tx, AuditActionDelete, id, before, nil, info)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Restore takes a university out of the trash
+func (m UniversityModel) Restore(id int64, info AuditInfo) (*University, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		UPDATE universities
+		SET deleted_at = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := m.getForUpdate(ctx, tx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	after := *before
+	after.DeletedAt = nil
+
+	err = insertAuditEvent(ctx, tx, AuditActionRestore, id, before, &after, info)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &after, nil
+}
+
+// Purge permanently deletes universities that have been in the trash for
+// longer than the retention period and returns how many were deleted, along
+// with the ids of their images. The image rows go with the universities, but
+// their files are left for the caller to remove from the blob store.
+func (m UniversityModel) Purge(retention time.Duration, info AuditInfo) (int64, []int64, error) {
+	// the audit events are written in the same statement as the delete, and
+	// the images are still seen there since the statement reads from a
+	// snapshot taken before it started
+	query := `
+		WITH purged AS (
+			DELETE FROM universities
+			WHERE deleted_at < $1
+			RETURNING id
+		), audited AS (
+			INSERT INTO audit_events (actor, action, university_id, request_id)
+			SELECT $2, $3, id, $4
+			FROM purged
+			RETURNING university_id
+		)
+		SELECT
+			(SELECT count(*) FROM audited),
+			ARRAY(SELECT id FROM images WHERE university_id IN (SELECT id FROM purged) ORDER BY id)`
+
+	args := []any{time.Now().Add(-retention), info.Actor, AuditActionPurge, info.RequestID}
+
+	// purging can touch many rows, so it gets more time than the other queries
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var (
+		purged   int64
+		imageIDs []int64
+	)
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&purged, pq.Array(&imageIDs))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return purged, imageIDs, nil
+}
+
+// getForUpdate fetches the current state of a university inside a transaction,
+// locking the row until the transaction ends. When deleted is true, only a
+// university in the trash is returned, otherwise only one that isn't.
+func (m UniversityModel) getForUpdate(ctx context.Context, tx *sql.Tx, id int64, deleted bool) (*University, error) {
+	var university University
+
+	columns, dest := university.scanFields(nil)
+
+	query := fmt.Sprintf(`
+		SELECT %s, deleted_at
+		FROM universities
+		WHERE id = $1 AND (deleted_at IS NOT NULL) = $2
+		FOR UPDATE`, columns)
+
+	err := tx.QueryRowContext(ctx, query, id, deleted).Scan(append(dest, &university.DeletedAt)...)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
 	}
 
-	return nil
+	return &university, nil
 }
 
-func (m UniversityModel) GetAll(name string, filters Filters) ([]*University, Metadata, error) {
+func (m UniversityModel) GetAll(q UniversityQuery, filters Filters) ([]*University, Metadata, error) {
+	where, args := q.where()
+
+	_, _, score := q.textSearch()
+	score = fmt.Sprintf("CASE WHEN $1 = '' THEN 0 ELSE %s END", score)
+
+	// earth_distance measures the great circle distance in metres
+	distance := "CASE WHEN $6::float8 IS NULL THEN NULL ELSE earth_distance(ll_to_earth($6, $7), ll_to_earth(latitude, longitude)) / 1000 END"
+
+	// the most relevant results come first when sorting by relevance
+	sortFields := filters.sortFields()
+	sortKeys := make([]string, len(sortFields))
+
+	for i, field := range sortFields {
+		switch field.column {
+		case "relevance":
+			sortFields[i] = sortField{column: score, direction: "DESC"}
+		case "founded":
+			sortFields[i].column = foundedKey
+		case "distance":
+			sortFields[i].column = distance
+		case "tuition":
+			// universities without tuition on record come last either way
+			if field.direction == "ASC" {
+				sortFields[i].column = fmt.Sprintf("COALESCE(%s, %d)", latestTuition, math.MaxInt64)
+			} else {
+				sortFields[i].column = fmt.Sprintf("COALESCE(%s, -1)", latestTuition)
+			}
+		}
+
+		sortKeys[i] = fmt.Sprintf("(%s)::text", sortFields[i].column)
+	}
+
+	// the limit and offset follow the filter parameters, then the keyset ones
+	limitPos := len(args) + 1
+	keysetCond, orderBy, keysetArgs := filters.keyset(sortFields, limitPos+2)
+
+	columns, _ := new(University).scanFields(q.Fields)
+
 	query := fmt.Sprintf(`
-	SELECT count(*) OVER(), id, created_at, name, founded, location, campuses, website, img_url, img_cite, version
+	SELECT count(*) OVER(), %s, %s AS score, %s AS distance_km, ARRAY[%s]
 	FROM universities
-	WHERE (to_tsvector('simple', name) @@ plainto_tsquery('simple', $1) OR $1 = '')
-	ORDER BY %s %s, id ASC
-	LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+	WHERE %s
+	AND %s
+	ORDER BY %s
+	LIMIT $%d OFFSET $%d`, columns, score, distance, strings.Join(sortKeys, ", "), where, keysetCond, orderBy, limitPos, limitPos+1)
+
+	args = append(args, filters.limit(), filters.offset())
+	args = append(args, keysetArgs...)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, name, filters.limit(), filters.offset())
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
@@ -184,24 +850,174 @@ func (m UniversityModel) GetAll(name string, filters Filters) ([]*University, Me
 
 	// we avoid the var keyword because we want to return an empty slice instead of nil
 	// to avoid encoding null in the JSON response if there are no universities found
+	universities := []*University{}
+	keys := []keysetKey{}
+	totalRecords := 0
+
+	for rows.Next() {
+		var (
+			university University
+			sortValues []string
+		)
+
+		_, dest := university.scanFields(q.Fields)
+
+		dest = append([]any{&totalRecords}, dest...)
+		dest = append(dest, &university.Score, &university.Distance, pq.Array(&sortValues))
+
+		err := rows.Scan(dest...)
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		universities = append(universities, &university)
+		keys = append(keys, keysetKey{ID: university.ID, Values: sortValues})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	universities, metadata := keysetPage(filters, universities, keys, totalRecords)
+
+	metadata.Facets, err = m.facets(ctx, where, args[:limitPos-1])
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	if len(universities) == 0 && filters.Cursor == "" && q.Name != "" {
+		metadata.Suggestions, err = m.suggest(ctx, q.Name)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+	}
+
+	return universities, metadata, nil
+}
+
+// facets counts the universities matching the WHERE conditions built by
+// UniversityQuery.where by institution type and by status
+func (m UniversityModel) facets(ctx context.Context, where string, args []any) (map[string]map[string]int, error) {
+	query := fmt.Sprintf(`
+	SELECT GROUPING(status) = 1, COALESCE(institution_type, 'unclassified'), COALESCE(status, ''), count(*)
+	FROM universities
+	WHERE %s
+	GROUP BY GROUPING SETS ((institution_type), (status))`, where)
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facets := map[string]map[string]int{
+		"institution_type": {},
+		"status":           {},
+	}
+
+	for rows.Next() {
+		var (
+			byType          bool
+			institutionType string
+			status          string
+			count           int
+		)
+
+		err := rows.Scan(&byType, &institutionType, &status, &count)
+		if err != nil {
+			return nil, err
+		}
+
+		if byType {
+			facets["institution_type"][institutionType] = count
+		} else {
+			facets["status"][status] = count
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// suggest returns up to three university names that are similar to the
+// search term, for "did you mean" hints when a search has no results. A
+// university similar by one of its alternate names is suggested by its
+// current name.
+func (m UniversityModel) suggest(ctx context.Context, name string) ([]string, error) {
+	query := `
+		SELECT u.name
+		FROM universities u
+		CROSS JOIN LATERAL (
+			SELECT GREATEST(word_similarity($1, u.name), max(word_similarity($1, n.name))) AS similarity
+			FROM university_names n
+			WHERE n.university_id = u.id
+		) s
+		WHERE u.deleted_at IS NULL AND s.similarity > 0.3
+		ORDER BY s.similarity DESC, u.id ASC
+		LIMIT 3`
+
+	rows, err := m.DB.QueryContext(ctx, query, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suggestions []string
+
+	for rows.Next() {
+		var suggestion string
+
+		err := rows.Scan(&suggestion)
+		if err != nil {
+			return nil, err
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// GetTrash returns the universities that are in the trash
+func (m UniversityModel) GetTrash(filters Filters) ([]*University, Metadata, error) {
+	columns, _ := new(University).scanFields(nil)
+
+	query := fmt.Sprintf(`
+	SELECT count(*) OVER(), %s, deleted_at
+	FROM universities
+	WHERE deleted_at IS NOT NULL
+	ORDER BY %s
+	LIMIT $1 OFFSET $2`, columns, filters.orderBy())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
 	universities := []*University{}
 	totalRecords := 0
 
 	for rows.Next() {
 		var university University
-		err := rows.Scan(
-			&totalRecords,
-			&university.ID,
-			&university.CreatedAt,
-			&university.Name,
-			&university.Founded,
-			&university.Location,
-			pq.Array(&university.Campuses),
-			&university.Website,
-			&university.ImgURL,
-			&university.ImgCite,
-			&university.Version)
 
+		_, dest := university.scanFields(nil)
+
+		dest = append([]any{&totalRecords}, dest...)
+		dest = append(dest, &university.DeletedAt)
+
+		err := rows.Scan(dest...)
 		if err != nil {
 			return nil, Metadata{}, err
 		}
@@ -0,0 +1,130 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+//go:embed "templates"
+var templateFS embed.FS
+
+// Mailer sends emails rendered from the templates in the templates directory.
+// Each template must define "subject", "plainBody" and "htmlBody" blocks.
+type Mailer interface {
+	Send(recipient, templateFile string, data any) error
+}
+
+// SMTPMailer is a Mailer that delivers email through an SMTP server. Pointing it
+// at a local SMTP sink such as Mailpit makes it easy to inspect emails during
+// development.
+type SMTPMailer struct {
+	addr   string
+	auth   smtp.Auth
+	sender string
+	// from is the bare address of the sender, which is what the SMTP
+	// envelope expects rather than a display name
+	from string
+}
+
+// NewSMTP returns an SMTPMailer for the given server. Authentication is skipped
+// when username is empty, which is what most local SMTP sinks expect. The
+// sender may include a display name, such as "Kolehiyo <no-reply@kolehiyo.live>".
+func NewSMTP(host string, port int, username, password, sender string) (*SMTPMailer, error) {
+	address, err := mail.ParseAddress(sender)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", sender, err)
+	}
+
+	m := &SMTPMailer{
+		addr:   fmt.Sprintf("%s:%d", host, port),
+		sender: sender,
+		from:   address.Address,
+	}
+
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return m, nil
+}
+
+func (m *SMTPMailer) Send(recipient, templateFile string, data any) error {
+	msg, err := render(m.sender, recipient, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{recipient}, msg)
+}
+
+// render executes the template and returns a multipart/alternative message
+// containing both the plain text and HTML bodies
+func render(sender, recipient, templateFile string, data any) ([]byte, error) {
+	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := new(bytes.Buffer)
+	err = tmpl.ExecuteTemplate(subject, "subject", data)
+	if err != nil {
+		return nil, err
+	}
+
+	plainBody := new(bytes.Buffer)
+	err = tmpl.ExecuteTemplate(plainBody, "plainBody", data)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlBody := new(bytes.Buffer)
+	err = tmpl.ExecuteTemplate(htmlBody, "htmlBody", data)
+	if err != nil {
+		return nil, err
+	}
+
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=UTF-8", plainBody.Bytes()},
+		{"text/html; charset=UTF-8", htmlBody.Bytes()},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = pw.Write(part.content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = mw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := new(bytes.Buffer)
+	fmt.Fprintf(msg, "From: %s\r\n", sender)
+	fmt.Fprintf(msg, "To: %s\r\n", recipient)
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject.String()))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
@@ -1,5 +1,11 @@
 package validator
 
+import "regexp"
+
+var (
+	EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+)
+
 type Validator struct {
 	Errors map[string]string
 }
@@ -34,6 +40,10 @@ func PermittedValue[T comparable](value T, permittedValues ...T) bool {
 	return false
 }
 
+func Matches(value string, rx *regexp.Regexp) bool {
+	return rx.MatchString(value)
+}
+
 func Unique[T comparable](values []T) bool {
 	uniqueValues := make(map[T]bool)
 	for _, value := range values {
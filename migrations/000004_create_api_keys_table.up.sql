@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id bigserial PRIMARY KEY,
+    hash bytea NOT NULL UNIQUE,
+    owner text NOT NULL,
+    created_at timestamp(0) WITH time zone NOT NULL DEFAULT NOW(),
+    revoked_at timestamp(0) WITH time zone
+);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS permissions (
+    id bigserial PRIMARY KEY,
+    code text NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS api_keys_permissions (
+    api_key_id bigint NOT NULL REFERENCES api_keys ON DELETE CASCADE,
+    permission_id bigint NOT NULL REFERENCES permissions ON DELETE CASCADE,
+    PRIMARY KEY (api_key_id, permission_id)
+);
+
+INSERT INTO permissions (code)
+VALUES
+    ('universities:create'),
+    ('universities:write'),
+    ('universities:delete');
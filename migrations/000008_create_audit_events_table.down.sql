@@ -0,0 +1,2 @@
+DELETE FROM permissions WHERE code = 'audit:read';
+DROP TABLE IF EXISTS audit_events;
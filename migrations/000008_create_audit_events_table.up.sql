@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) WITH time zone NOT NULL DEFAULT NOW(),
+    actor text NOT NULL,
+    action text NOT NULL,
+    university_id bigint NOT NULL,
+    before jsonb,
+    after jsonb,
+    request_id text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_university_id_idx ON audit_events (university_id);
+
+INSERT INTO permissions (code)
+VALUES
+    ('audit:read');
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS university_revisions;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS university_revisions (
+    university_id bigint NOT NULL REFERENCES universities ON DELETE CASCADE,
+    version integer NOT NULL,
+    created_at timestamp(0) WITH time zone NOT NULL DEFAULT NOW(),
+    snapshot jsonb NOT NULL,
+    PRIMARY KEY (university_id, version)
+);
+
+-- keep the current state of existing universities as their first known revision
+INSERT INTO university_revisions (university_id, version, snapshot)
+SELECT id, version, to_jsonb(universities)
+FROM universities
+ON CONFLICT DO NOTHING;
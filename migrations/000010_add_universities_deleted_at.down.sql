@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE code = 'universities:admin';
+DROP INDEX IF EXISTS universities_deleted_at_idx;
+ALTER TABLE universities DROP COLUMN IF EXISTS deleted_at;
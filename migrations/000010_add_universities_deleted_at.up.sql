@@ -0,0 +1,8 @@
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS deleted_at timestamp(0) WITH time zone;
+
+-- only trashed rows are indexed, which keeps the trash listing and purge fast
+CREATE INDEX IF NOT EXISTS universities_deleted_at_idx ON universities (deleted_at) WHERE deleted_at IS NOT NULL;
+
+INSERT INTO permissions (code)
+VALUES
+    ('universities:admin');
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS universities_founded_idx;
+DROP INDEX IF EXISTS universities_location_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS universities_location_idx ON universities USING GIN (to_tsvector('simple', location));
+CREATE INDEX IF NOT EXISTS universities_founded_idx ON universities (founded);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS universities_location_trgm_idx;
+DROP INDEX IF EXISTS universities_name_trgm_idx;
@@ -0,0 +1,4 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS universities_name_trgm_idx ON universities USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS universities_location_trgm_idx ON universities USING GIN (location gin_trgm_ops);
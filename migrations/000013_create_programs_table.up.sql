@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS programs (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) WITH time zone NOT NULL DEFAULT NOW(),
+    university_id bigint NOT NULL REFERENCES universities ON DELETE CASCADE,
+    name text NOT NULL,
+    level text NOT NULL,
+    discipline text NOT NULL,
+    duration_months integer,
+    status text NOT NULL,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT programs_level_check CHECK (level IN ('certificate', 'associate', 'bachelor', 'master', 'doctorate')),
+    CONSTRAINT programs_status_check CHECK (status IN ('active', 'suspended', 'discontinued')),
+    CONSTRAINT programs_duration_months_check CHECK (duration_months > 0),
+    CONSTRAINT programs_university_name_level_key UNIQUE (university_id, name, level)
+);
+
+CREATE INDEX IF NOT EXISTS programs_name_idx ON programs USING GIN (to_tsvector('simple', name));
+CREATE INDEX IF NOT EXISTS programs_discipline_idx ON programs (lower(discipline));
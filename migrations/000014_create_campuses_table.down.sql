@@ -0,0 +1,7 @@
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS campuses text [];
+
+UPDATE universities u
+SET campuses = ARRAY(SELECT c.name FROM campuses c WHERE c.university_id = u.id ORDER BY c.id)
+WHERE EXISTS (SELECT 1 FROM campuses c WHERE c.university_id = u.id);
+
+DROP TABLE IF EXISTS campuses;
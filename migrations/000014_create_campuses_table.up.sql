@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS campuses (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) WITH time zone NOT NULL DEFAULT NOW(),
+    university_id bigint NOT NULL REFERENCES universities ON DELETE CASCADE,
+    name text NOT NULL,
+    address text NOT NULL DEFAULT '',
+    city text NOT NULL DEFAULT '',
+    latitude double precision,
+    longitude double precision,
+    founded integer,
+    website text NOT NULL DEFAULT '',
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT campuses_latitude_check CHECK (latitude BETWEEN -90 AND 90),
+    CONSTRAINT campuses_longitude_check CHECK (longitude BETWEEN -180 AND 180),
+    CONSTRAINT campuses_coordinates_check CHECK ((latitude IS NULL) = (longitude IS NULL)),
+    CONSTRAINT campuses_founded_check CHECK (founded >= 1589),
+    CONSTRAINT campuses_university_name_key UNIQUE (university_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS campuses_name_idx ON campuses (lower(name));
+CREATE INDEX IF NOT EXISTS campuses_city_idx ON campuses (lower(city));
+
+-- carry the existing campus names over, keeping the order they were listed in
+INSERT INTO campuses (university_id, name)
+SELECT university_id, name
+FROM (
+    SELECT DISTINCT ON (u.id, c.name) u.id AS university_id, c.name, c.position
+    FROM universities u
+    CROSS JOIN LATERAL unnest(u.campuses) WITH ORDINALITY AS c(name, position)
+    WHERE c.name IS NOT NULL
+    ORDER BY u.id, c.name, c.position
+) AS listed
+ORDER BY university_id, position;
+
+ALTER TABLE universities DROP COLUMN IF EXISTS campuses;
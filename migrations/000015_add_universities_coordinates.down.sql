@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS universities_coordinates_idx;
+
+ALTER TABLE universities DROP CONSTRAINT IF EXISTS universities_longitude_check;
+ALTER TABLE universities DROP CONSTRAINT IF EXISTS universities_latitude_check;
+ALTER TABLE universities DROP CONSTRAINT IF EXISTS universities_coordinates_check;
+
+ALTER TABLE universities DROP COLUMN IF EXISTS longitude;
+ALTER TABLE universities DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,11 @@
+CREATE EXTENSION IF NOT EXISTS cube;
+CREATE EXTENSION IF NOT EXISTS earthdistance;
+
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS latitude double precision;
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS longitude double precision;
+
+ALTER TABLE universities ADD CONSTRAINT universities_coordinates_check CHECK ((latitude IS NULL) = (longitude IS NULL));
+ALTER TABLE universities ADD CONSTRAINT universities_latitude_check CHECK (latitude BETWEEN 4.5 AND 21.5);
+ALTER TABLE universities ADD CONSTRAINT universities_longitude_check CHECK (longitude BETWEEN 116 AND 127);
+
+CREATE INDEX IF NOT EXISTS universities_coordinates_idx ON universities USING GIST (ll_to_earth(latitude, longitude)) WHERE latitude IS NOT NULL;
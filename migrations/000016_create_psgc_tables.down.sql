@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS universities_city_code_idx;
+DROP INDEX IF EXISTS universities_province_code_idx;
+DROP INDEX IF EXISTS universities_region_code_idx;
+
+ALTER TABLE universities DROP COLUMN IF EXISTS city_code;
+ALTER TABLE universities DROP COLUMN IF EXISTS province_code;
+ALTER TABLE universities DROP COLUMN IF EXISTS region_code;
+
+DROP TABLE IF EXISTS cities;
+DROP TABLE IF EXISTS provinces;
+DROP TABLE IF EXISTS regions;
@@ -0,0 +1,140 @@
+CREATE TABLE IF NOT EXISTS regions (
+    code char(10) PRIMARY KEY,
+    name text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS provinces (
+    code char(10) PRIMARY KEY,
+    region_code char(10) NOT NULL REFERENCES regions,
+    name text NOT NULL
+);
+
+-- cities in Metro Manila and highly urbanized cities don't belong to a province
+CREATE TABLE IF NOT EXISTS cities (
+    code char(10) PRIMARY KEY,
+    region_code char(10) NOT NULL REFERENCES regions,
+    province_code char(10) REFERENCES provinces,
+    name text NOT NULL,
+    kind text NOT NULL,
+    CONSTRAINT cities_kind_check CHECK (kind IN ('city', 'municipality'))
+);
+
+CREATE INDEX IF NOT EXISTS provinces_region_code_idx ON provinces (region_code);
+CREATE INDEX IF NOT EXISTS cities_region_code_idx ON cities (region_code);
+CREATE INDEX IF NOT EXISTS cities_province_code_idx ON cities (province_code);
+
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS region_code char(10) REFERENCES regions;
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS province_code char(10) REFERENCES provinces;
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS city_code char(10) REFERENCES cities;
+
+CREATE INDEX IF NOT EXISTS universities_region_code_idx ON universities (region_code);
+CREATE INDEX IF NOT EXISTS universities_province_code_idx ON universities (province_code);
+CREATE INDEX IF NOT EXISTS universities_city_code_idx ON universities (city_code);
+
+-- regions and provinces from the Philippine Standard Geographic Code. Cities
+-- and municipalities are loaded from the full PSGC publication by cmd/psgc.
+INSERT INTO regions (code, name) VALUES
+    ('0100000000', 'Region I (Ilocos Region)'),
+    ('0200000000', 'Region II (Cagayan Valley)'),
+    ('0300000000', 'Region III (Central Luzon)'),
+    ('0400000000', 'Region IV-A (CALABARZON)'),
+    ('0500000000', 'Region V (Bicol Region)'),
+    ('0600000000', 'Region VI (Western Visayas)'),
+    ('0700000000', 'Region VII (Central Visayas)'),
+    ('0800000000', 'Region VIII (Eastern Visayas)'),
+    ('0900000000', 'Region IX (Zamboanga Peninsula)'),
+    ('1000000000', 'Region X (Northern Mindanao)'),
+    ('1100000000', 'Region XI (Davao Region)'),
+    ('1200000000', 'Region XII (SOCCSKSARGEN)'),
+    ('1300000000', 'National Capital Region (NCR)'),
+    ('1400000000', 'Cordillera Administrative Region (CAR)'),
+    ('1600000000', 'Region XIII (Caraga)'),
+    ('1700000000', 'MIMAROPA Region'),
+    ('1800000000', 'Negros Island Region (NIR)'),
+    ('1900000000', 'Bangsamoro Autonomous Region in Muslim Mindanao (BARMM)')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO provinces (code, region_code, name) VALUES
+    ('0102800000', '0100000000', 'Ilocos Norte'),
+    ('0102900000', '0100000000', 'Ilocos Sur'),
+    ('0103300000', '0100000000', 'La Union'),
+    ('0105500000', '0100000000', 'Pangasinan'),
+    ('0200900000', '0200000000', 'Batanes'),
+    ('0201500000', '0200000000', 'Cagayan'),
+    ('0203100000', '0200000000', 'Isabela'),
+    ('0205000000', '0200000000', 'Nueva Vizcaya'),
+    ('0205700000', '0200000000', 'Quirino'),
+    ('0300800000', '0300000000', 'Bataan'),
+    ('0301400000', '0300000000', 'Bulacan'),
+    ('0304900000', '0300000000', 'Nueva Ecija'),
+    ('0305400000', '0300000000', 'Pampanga'),
+    ('0306900000', '0300000000', 'Tarlac'),
+    ('0307100000', '0300000000', 'Zambales'),
+    ('0307700000', '0300000000', 'Aurora'),
+    ('0401000000', '0400000000', 'Batangas'),
+    ('0402100000', '0400000000', 'Cavite'),
+    ('0403400000', '0400000000', 'Laguna'),
+    ('0405600000', '0400000000', 'Quezon'),
+    ('0405800000', '0400000000', 'Rizal'),
+    ('0500500000', '0500000000', 'Albay'),
+    ('0501600000', '0500000000', 'Camarines Norte'),
+    ('0501700000', '0500000000', 'Camarines Sur'),
+    ('0502000000', '0500000000', 'Catanduanes'),
+    ('0504100000', '0500000000', 'Masbate'),
+    ('0506200000', '0500000000', 'Sorsogon'),
+    ('0600400000', '0600000000', 'Aklan'),
+    ('0600600000', '0600000000', 'Antique'),
+    ('0601900000', '0600000000', 'Capiz'),
+    ('0603000000', '0600000000', 'Iloilo'),
+    ('0607900000', '0600000000', 'Guimaras'),
+    ('0701200000', '0700000000', 'Bohol'),
+    ('0702200000', '0700000000', 'Cebu'),
+    ('0802600000', '0800000000', 'Eastern Samar'),
+    ('0803700000', '0800000000', 'Leyte'),
+    ('0804800000', '0800000000', 'Northern Samar'),
+    ('0806000000', '0800000000', 'Samar'),
+    ('0806400000', '0800000000', 'Southern Leyte'),
+    ('0807800000', '0800000000', 'Biliran'),
+    ('0907200000', '0900000000', 'Zamboanga del Norte'),
+    ('0907300000', '0900000000', 'Zamboanga del Sur'),
+    ('0908300000', '0900000000', 'Zamboanga Sibugay'),
+    ('1001300000', '1000000000', 'Bukidnon'),
+    ('1001800000', '1000000000', 'Camiguin'),
+    ('1003500000', '1000000000', 'Lanao del Norte'),
+    ('1004200000', '1000000000', 'Misamis Occidental'),
+    ('1004300000', '1000000000', 'Misamis Oriental'),
+    ('1102300000', '1100000000', 'Davao del Norte'),
+    ('1102400000', '1100000000', 'Davao del Sur'),
+    ('1102500000', '1100000000', 'Davao Oriental'),
+    ('1108200000', '1100000000', 'Davao de Oro'),
+    ('1108600000', '1100000000', 'Davao Occidental'),
+    ('1204700000', '1200000000', 'Cotabato'),
+    ('1206300000', '1200000000', 'South Cotabato'),
+    ('1206500000', '1200000000', 'Sultan Kudarat'),
+    ('1208000000', '1200000000', 'Sarangani'),
+    ('1400100000', '1400000000', 'Abra'),
+    ('1401100000', '1400000000', 'Benguet'),
+    ('1402700000', '1400000000', 'Ifugao'),
+    ('1403200000', '1400000000', 'Kalinga'),
+    ('1404400000', '1400000000', 'Mountain Province'),
+    ('1408100000', '1400000000', 'Apayao'),
+    ('1600200000', '1600000000', 'Agusan del Norte'),
+    ('1600300000', '1600000000', 'Agusan del Sur'),
+    ('1606700000', '1600000000', 'Surigao del Norte'),
+    ('1606800000', '1600000000', 'Surigao del Sur'),
+    ('1608500000', '1600000000', 'Dinagat Islands'),
+    ('1704000000', '1700000000', 'Marinduque'),
+    ('1705100000', '1700000000', 'Occidental Mindoro'),
+    ('1705200000', '1700000000', 'Oriental Mindoro'),
+    ('1705300000', '1700000000', 'Palawan'),
+    ('1705900000', '1700000000', 'Romblon'),
+    ('1804500000', '1800000000', 'Negros Occidental'),
+    ('1804600000', '1800000000', 'Negros Oriental'),
+    ('1806100000', '1800000000', 'Siquijor'),
+    ('1900700000', '1900000000', 'Basilan'),
+    ('1903600000', '1900000000', 'Lanao del Sur'),
+    ('1906600000', '1900000000', 'Sulu'),
+    ('1907000000', '1900000000', 'Tawi-Tawi'),
+    ('1908700000', '1900000000', 'Maguindanao del Norte'),
+    ('1908800000', '1900000000', 'Maguindanao del Sur')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS universities_status_idx;
+DROP INDEX IF EXISTS universities_institution_type_idx;
+
+ALTER TABLE universities DROP CONSTRAINT IF EXISTS universities_status_check;
+ALTER TABLE universities DROP CONSTRAINT IF EXISTS universities_institution_type_check;
+
+ALTER TABLE universities DROP COLUMN IF EXISTS status;
+ALTER TABLE universities DROP COLUMN IF EXISTS institution_type;
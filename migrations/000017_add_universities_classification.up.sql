@@ -0,0 +1,8 @@
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS institution_type text;
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'active';
+
+ALTER TABLE universities ADD CONSTRAINT universities_institution_type_check CHECK (institution_type IN ('suc', 'luc', 'private_non_sectarian', 'private_sectarian', 'other_government'));
+ALTER TABLE universities ADD CONSTRAINT universities_status_check CHECK (status IN ('active', 'merged', 'closed'));
+
+CREATE INDEX IF NOT EXISTS universities_institution_type_idx ON universities (institution_type);
+CREATE INDEX IF NOT EXISTS universities_status_idx ON universities (status);
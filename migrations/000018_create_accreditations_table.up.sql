@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS accreditations (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) WITH time zone NOT NULL DEFAULT NOW(),
+    university_id bigint NOT NULL REFERENCES universities ON DELETE CASCADE,
+    program_id bigint REFERENCES programs ON DELETE CASCADE,
+    kind text NOT NULL,
+    body text NOT NULL,
+    level integer NOT NULL DEFAULT 0,
+    scope text NOT NULL,
+    valid_from date NOT NULL,
+    valid_to date,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT accreditations_kind_check CHECK (kind IN ('accreditation', 'center_of_excellence', 'center_of_development')),
+    CONSTRAINT accreditations_level_check CHECK (level BETWEEN 0 AND 4),
+    CONSTRAINT accreditations_validity_check CHECK (valid_to >= valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS accreditations_university_id_idx ON accreditations (university_id);
+CREATE INDEX IF NOT EXISTS accreditations_program_id_idx ON accreditations (program_id);
+CREATE INDEX IF NOT EXISTS accreditations_body_level_idx ON accreditations (body, level);
+CREATE INDEX IF NOT EXISTS accreditations_scope_idx ON accreditations USING GIN (to_tsvector('simple', scope));
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS tuition_fees (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) WITH time zone NOT NULL DEFAULT NOW(),
+    university_id bigint NOT NULL REFERENCES universities ON DELETE CASCADE,
+    program_id bigint REFERENCES programs ON DELETE CASCADE,
+    academic_year integer NOT NULL,
+    unit text NOT NULL,
+    amount bigint NOT NULL,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT tuition_fees_unit_check CHECK (unit IN ('per_unit', 'per_semester')),
+    CONSTRAINT tuition_fees_amount_check CHECK (amount >= 0)
+);
+
+-- a fee that applies to the whole university has no program, and there is
+-- only one fee for each academic year and unit
+CREATE UNIQUE INDEX IF NOT EXISTS tuition_fees_key ON tuition_fees (university_id, COALESCE(program_id, 0), academic_year, unit);
+CREATE INDEX IF NOT EXISTS tuition_fees_program_id_idx ON tuition_fees (program_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS university_names (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) WITH time zone NOT NULL DEFAULT NOW(),
+    university_id bigint NOT NULL REFERENCES universities ON DELETE CASCADE,
+    name text NOT NULL,
+    kind text NOT NULL,
+    valid_from integer,
+    valid_to integer,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT university_names_kind_check CHECK (kind IN ('acronym', 'former_name', 'translation')),
+    CONSTRAINT university_names_validity_check CHECK (valid_to >= valid_from),
+    CONSTRAINT university_names_university_name_key UNIQUE (university_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS university_names_name_idx ON university_names USING GIN (to_tsvector('simple', name));
+CREATE INDEX IF NOT EXISTS university_names_name_trgm_idx ON university_names USING GIN (name gin_trgm_ops);
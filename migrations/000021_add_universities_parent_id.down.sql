@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS universities_parent_id_idx;
+
+ALTER TABLE universities DROP CONSTRAINT IF EXISTS universities_parent_id_check;
+
+ALTER TABLE universities DROP COLUMN IF EXISTS parent_id;
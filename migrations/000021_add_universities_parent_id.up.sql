@@ -0,0 +1,5 @@
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS parent_id bigint REFERENCES universities ON DELETE SET NULL;
+
+ALTER TABLE universities ADD CONSTRAINT universities_parent_id_check CHECK (parent_id <> id);
+
+CREATE INDEX IF NOT EXISTS universities_parent_id_idx ON universities (parent_id);
@@ -0,0 +1,3 @@
+ALTER TABLE universities DROP CONSTRAINT IF EXISTS universities_founded_precision_check;
+
+ALTER TABLE universities DROP COLUMN IF EXISTS founded_precision;
@@ -0,0 +1,7 @@
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS founded_precision text NOT NULL DEFAULT 'day';
+
+ALTER TABLE universities ADD CONSTRAINT universities_founded_precision_check CHECK (founded_precision IN ('year', 'month', 'day'));
+
+-- founding dates used to be shown as a year only, so clients often sent
+-- January 1 for schools only known to the year
+UPDATE universities SET founded_precision = 'year' WHERE date_trunc('year', founded) = founded;
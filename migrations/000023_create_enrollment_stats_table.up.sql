@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS enrollment_stats (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) WITH time zone NOT NULL DEFAULT NOW(),
+    university_id bigint NOT NULL REFERENCES universities ON DELETE CASCADE,
+    academic_year integer NOT NULL,
+    level text NOT NULL,
+    sex text NOT NULL,
+    enrolled integer NOT NULL,
+    graduates integer NOT NULL DEFAULT 0,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT enrollment_stats_level_check CHECK (level IN ('certificate', 'associate', 'bachelor', 'master', 'doctorate')),
+    CONSTRAINT enrollment_stats_sex_check CHECK (sex IN ('female', 'male')),
+    CONSTRAINT enrollment_stats_enrolled_check CHECK (enrolled >= 0),
+    CONSTRAINT enrollment_stats_graduates_check CHECK (graduates >= 0 AND graduates <= enrolled),
+    CONSTRAINT enrollment_stats_key UNIQUE (university_id, academic_year, level, sex)
+);
+
+CREATE INDEX IF NOT EXISTS enrollment_stats_academic_year_idx ON enrollment_stats (academic_year);
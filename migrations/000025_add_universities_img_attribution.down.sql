@@ -0,0 +1,3 @@
+ALTER TABLE universities DROP CONSTRAINT IF EXISTS universities_img_attribution_check;
+
+ALTER TABLE universities DROP COLUMN IF EXISTS img_attribution;
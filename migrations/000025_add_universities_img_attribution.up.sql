@@ -0,0 +1,3 @@
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS img_attribution jsonb;
+
+ALTER TABLE universities ADD CONSTRAINT universities_img_attribution_check CHECK (jsonb_typeof(img_attribution) = 'object');
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS university_slugs;
+
+ALTER TABLE universities DROP CONSTRAINT IF EXISTS universities_slug_key;
+
+ALTER TABLE universities DROP COLUMN IF EXISTS slug;
@@ -0,0 +1,48 @@
+ALTER TABLE universities ADD COLUMN IF NOT EXISTS slug text;
+
+-- the constraint goes on before the backfill so that its index speeds up the
+-- lookups below
+ALTER TABLE universities ADD CONSTRAINT universities_slug_key UNIQUE (slug);
+
+-- existing universities get the slugs the API would have generated. They are
+-- given out in the order the universities were added, each taking the first
+-- of its base slug, "-2", "-3" and so on that no university has taken yet,
+-- since a numbered slug such as "foo-2" can also be the base of another name.
+DO $$
+DECLARE
+    university record;
+    base text;
+    candidate text;
+    n int;
+BEGIN
+    FOR university IN SELECT id, name FROM universities ORDER BY id LOOP
+        base := trim(both '-' from left(trim(both '-' from regexp_replace(translate(lower(university.name), 'áéíóúàèìòùñü', 'aeiouaeiounu'), '[^a-z0-9]+', '-', 'g')), 80));
+
+        IF base ~ '^[0-9]*$' THEN
+            base := trim(both '-' from 'university-' || base);
+        END IF;
+
+        candidate := base;
+        n := 2;
+
+        WHILE candidate = 'trash' OR EXISTS (SELECT 1 FROM universities WHERE slug = candidate) LOOP
+            candidate := base || '-' || n;
+            n := n + 1;
+        END LOOP;
+
+        UPDATE universities SET slug = candidate WHERE id = university.id;
+    END LOOP;
+END
+$$;
+
+ALTER TABLE universities ALTER COLUMN slug SET NOT NULL;
+
+-- slugs a university was known by before its name changed, kept so that
+-- links using them can be redirected
+CREATE TABLE IF NOT EXISTS university_slugs (
+    slug text PRIMARY KEY,
+    created_at timestamp(0) WITH time zone NOT NULL DEFAULT NOW(),
+    university_id bigint NOT NULL REFERENCES universities ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS university_slugs_university_id_idx ON university_slugs (university_id);